@@ -0,0 +1,242 @@
+package logging
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// defaultAsyncSize is the default number of entries in the queue.
+	defaultAsyncSize = 1024
+	// defaultFlushTimeout is the default time to wait for the queue to drain.
+	defaultFlushTimeout = 5 * time.Second
+)
+
+// BackpressurePolicy defines what happens when an entry is written and the
+// queue of an asynchronous sink is full.
+type BackpressurePolicy string
+
+const (
+	// PolicyBlock blocks the caller until there is space in the queue.
+	PolicyBlock BackpressurePolicy = "block"
+	// PolicyDropNewest drops the entry being written.
+	PolicyDropNewest BackpressurePolicy = "drop-newest"
+	// PolicyDropOldest drops the oldest entry in the queue to make space for
+	// the new one.
+	PolicyDropOldest BackpressurePolicy = "drop-oldest"
+)
+
+// Async configures the asynchronous writing of entries. Each sink gets a
+// bounded queue and a background goroutine that writes the entries to the
+// output.
+type Async struct {
+	// Size is the maximum number of entries in the queue. Defaults to 1024.
+	Size int `json:"size"`
+	// Policy is the policy used when the queue is full. Defaults to
+	// "block".
+	Policy BackpressurePolicy `json:"policy"`
+	// FlushTimeout is the maximum time that Sync waits for the queue to drain.
+	// Defaults to 5s.
+	FlushTimeout Duration `json:"flushTimeout"`
+}
+
+// Validate validates the asynchronous configuration.
+func (a *Async) Validate() error {
+	v := new(validator)
+	a.validate(v, "async")
+	return v.err()
+}
+
+func (a *Async) validate(v *validator, path string) {
+	if a == nil {
+		return
+	}
+	if a.Size < 0 {
+		v.add(path+".size", "cannot be negative")
+	}
+	if a.FlushTimeout.Duration < 0 {
+		v.add(path+".flushTimeout", "cannot be negative")
+	}
+	switch a.Policy {
+	case "", PolicyBlock, PolicyDropNewest, PolicyDropOldest:
+	default:
+		v.add(path+".policy", "unsupported policy '%s'", a.Policy)
+	}
+}
+
+// asyncWriter is a zapcore.WriteSyncer that queues the writes and writes them
+// in a background goroutine.
+type asyncWriter struct {
+	out          zapcore.WriteSyncer
+	policy       BackpressurePolicy
+	flushTimeout time.Duration
+	queue        chan []byte
+	syncCh       chan chan error
+	// stopCh is closed to unblock the writers when the writer is closed, and
+	// closedCh once no more entries can be queued.
+	stopCh   chan struct{}
+	closedCh chan struct{}
+	doneCh   chan struct{}
+	stopOnce sync.Once
+	dropped  atomic.Uint64
+	// mu guards closed; the writers hold a read lock while queueing.
+	mu     sync.RWMutex
+	closed bool
+}
+
+// errAsyncClosed is returned when writing to a closed asynchronous writer.
+var errAsyncClosed = errors.New("logger is closed")
+
+func newAsyncWriter(out zapcore.WriteSyncer, a *Async) *asyncWriter {
+	size := a.Size
+	if size == 0 {
+		size = defaultAsyncSize
+	}
+	policy := a.Policy
+	if policy == "" {
+		policy = PolicyBlock
+	}
+	flushTimeout := a.FlushTimeout.Duration
+	if flushTimeout == 0 {
+		flushTimeout = defaultFlushTimeout
+	}
+
+	w := &asyncWriter{
+		out:          out,
+		policy:       policy,
+		flushTimeout: flushTimeout,
+		queue:        make(chan []byte, size),
+		syncCh:       make(chan chan error),
+		stopCh:       make(chan struct{}),
+		closedCh:     make(chan struct{}),
+		doneCh:       make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Write queues a copy of p using the configured backpressure policy. It fails
+// if the writer is closed.
+func (w *asyncWriter) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return 0, errAsyncClosed
+	}
+
+	// The encoder buffer is reused after the write.
+	b := make([]byte, len(p))
+	copy(b, p)
+
+	switch w.policy {
+	case PolicyDropNewest:
+		select {
+		case w.queue <- b:
+		case <-w.stopCh:
+			return 0, errAsyncClosed
+		default:
+			w.dropped.Add(1)
+		}
+	case PolicyDropOldest:
+		for {
+			select {
+			case w.queue <- b:
+				return len(p), nil
+			case <-w.stopCh:
+				return 0, errAsyncClosed
+			default:
+				select {
+				case <-w.queue:
+					w.dropped.Add(1)
+				default:
+				}
+			}
+		}
+	default:
+		select {
+		case w.queue <- b:
+		case <-w.stopCh:
+			return 0, errAsyncClosed
+		}
+	}
+	return len(p), nil
+}
+
+// Sync waits until the entries in the queue are written and syncs the output.
+// It fails if the queue is not drained before the flush timeout.
+func (w *asyncWriter) Sync() error {
+	timer := time.NewTimer(w.flushTimeout)
+	defer timer.Stop()
+
+	ch := make(chan error, 1)
+	select {
+	case w.syncCh <- ch:
+	case <-w.doneCh:
+		return nil
+	case <-timer.C:
+		return errors.New("timeout flushing the logger queue")
+	}
+
+	select {
+	case err := <-ch:
+		return err
+	case <-timer.C:
+		return errors.New("timeout flushing the logger queue")
+	}
+}
+
+// Close drains the queue and stops the background goroutine. The writes after
+// Close fail.
+func (w *asyncWriter) Close() error {
+	w.stopOnce.Do(func() {
+		// Unblock the writers waiting for space in the queue, and wait for
+		// the ones in progress before draining the queue.
+		close(w.stopCh)
+		w.mu.Lock()
+		w.closed = true
+		w.mu.Unlock()
+		close(w.closedCh)
+	})
+
+	timer := time.NewTimer(w.flushTimeout)
+	defer timer.Stop()
+	select {
+	case <-w.doneCh:
+		return nil
+	case <-timer.C:
+		return errors.New("timeout flushing the logger queue")
+	}
+}
+
+func (w *asyncWriter) run() {
+	defer close(w.doneCh)
+	for {
+		select {
+		case b := <-w.queue:
+			_, _ = w.out.Write(b)
+		case ch := <-w.syncCh:
+			w.drain()
+			ch <- w.out.Sync()
+		case <-w.closedCh:
+			w.drain()
+			_ = w.out.Sync()
+			return
+		}
+	}
+}
+
+// drain writes the entries in the queue without blocking.
+func (w *asyncWriter) drain() {
+	for {
+		select {
+		case b := <-w.queue:
+			_, _ = w.out.Write(b)
+		default:
+			return
+		}
+	}
+}
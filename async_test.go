@@ -0,0 +1,125 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// blockingWriter blocks the writes until it is released. If entered is set,
+// it is signaled when a write starts.
+type blockingWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	select {
+	case w.entered <- struct{}{}:
+	default:
+	}
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestLogger_async(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  BackpressurePolicy
+		want    []string
+		dropped uint64
+	}{
+		{"drop-newest", PolicyDropNewest, []string{"message 0", "message 1", "message 2"}, 2},
+		{"drop-oldest", PolicyDropOldest, []string{"message 0", "message 3", "message 4"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &blockingWriter{entered: make(chan struct{}, 1), release: make(chan struct{})}
+			logger, err := New("test", WithFormatJSON(),
+				WithSink("out", w, AllLevels(), ""),
+				WithAsync(&Async{Size: 2, Policy: tt.policy}),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			logger.Info("message 0")
+			// Wait until the first message is being written.
+			<-w.entered
+			for _, s := range []string{"message 1", "message 2", "message 3", "message 4"} {
+				logger.Info(s)
+			}
+			close(w.release)
+			if err := logger.Sync(); err != nil {
+				t.Fatalf("Logger.Sync() error = %v", err)
+			}
+
+			s := w.String()
+			if n := strings.Count(s, "\n"); n != len(tt.want) {
+				t.Errorf("entries = %d, want %d: %s", n, len(tt.want), s)
+			}
+			for _, m := range tt.want {
+				if !strings.Contains(s, m) {
+					t.Errorf("output does not contain %q: %s", m, s)
+				}
+			}
+			if stats := logger.SinkStats(); stats[0].Dropped != tt.dropped {
+				t.Errorf("Logger.SinkStats() = %+v, want %d dropped", stats, tt.dropped)
+			}
+			if err := logger.Close(); err != nil {
+				t.Errorf("Logger.Close() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestLogger_asyncSyncTimeout(t *testing.T) {
+	w := &blockingWriter{release: make(chan struct{})}
+	defer close(w.release)
+
+	logger, err := New("test", WithSink("out", w, AllLevels(), ""),
+		WithAsync(&Async{FlushTimeout: Duration{50 * time.Millisecond}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("message")
+	if err := logger.Sync(); err == nil {
+		t.Error("Logger.Sync() error = nil, wantErr true")
+	}
+}
+
+func TestAsyncWriter_writeAfterClose(t *testing.T) {
+	for _, policy := range []BackpressurePolicy{PolicyBlock, PolicyDropNewest, PolicyDropOldest} {
+		t.Run(string(policy), func(t *testing.T) {
+			var buf bytes.Buffer
+			w := newAsyncWriter(zapcore.AddSync(&buf), &Async{Size: 2, Policy: policy})
+			if _, err := w.Write([]byte("before\n")); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if _, err := w.Write([]byte("after\n")); err == nil {
+				t.Error("Write() error = nil, wantErr true")
+			}
+			if s := buf.String(); s != "before\n" {
+				t.Errorf("output = %q, want %q", s, "before\n")
+			}
+		})
+	}
+}
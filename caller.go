@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Caller configures the annotation of the entries with the file and line of
+// the caller.
+type Caller struct {
+	// FullPath writes the full path of the file instead of the package and
+	// file name.
+	FullPath bool `json:"fullPath"`
+	// Function adds the name of the function in the "function" field.
+	Function bool `json:"function"`
+}
+
+// WithCaller enables the annotation of the entries with the caller.
+func WithCaller(c *Caller) Option {
+	return func(o *options) error {
+		o.Caller = c
+		return nil
+	}
+}
+
+// WithStacktrace adds a stack trace to the entries at or above the given
+// level.
+func WithStacktrace(level Level) Option {
+	return func(o *options) error {
+		o.StacktraceLevel = &level
+		return nil
+	}
+}
+
+// encoderConfig sets the caller keys and encoder in the given configuration.
+func (c *Caller) encoderConfig(config *zapcore.EncoderConfig) {
+	if c == nil {
+		return
+	}
+	if c.FullPath {
+		config.EncodeCaller = zapcore.FullCallerEncoder
+	}
+	if c.Function {
+		config.FunctionKey = "function"
+	}
+}
+
+// callerOptions returns the zap options that enable the caller and the stack
+// traces.
+func (o *options) callerOptions() []zap.Option {
+	var opts []zap.Option
+	if o.Caller != nil {
+		opts = append(opts, zap.AddCaller())
+	}
+	if o.StacktraceLevel != nil {
+		level := *o.StacktraceLevel
+		opts = append(opts, zap.AddStacktrace(zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+			return Level(l) >= level
+		})))
+	}
+	return opts
+}
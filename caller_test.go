@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestLogger_caller(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New("test",
+		WithSink("out", &out, AllLevels(), "json"),
+		WithCaller(&Caller{Function: true}),
+		WithStacktrace(ErrorLevel),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	logger.Info("info message")
+	logger.ErrorContext(t.Context(), "error message")
+
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v", err)
+		}
+		entries = append(entries, m)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	for _, e := range entries {
+		if s, _ := e["caller"].(string); strings.HasPrefix(s, "/") || !strings.Contains(s, "/caller_test.go:") {
+			t.Errorf("caller = %v, want dir/caller_test.go:line", e["caller"])
+		}
+		if e["function"] != "github.com/smallstep/logging.TestLogger_caller" {
+			t.Errorf("function = %v", e["function"])
+		}
+	}
+	if _, ok := entries[0]["stacktrace"]; ok {
+		t.Error("info entry has a stacktrace")
+	}
+	if s, _ := entries[1]["stacktrace"].(string); !strings.HasPrefix(s, "github.com/smallstep/logging.TestLogger_caller") {
+		t.Errorf("stacktrace = %v", entries[1]["stacktrace"])
+	}
+}
+
+func TestLogger_callerFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"text", []string{"caller_test.go:", "\n  stacktrace:\n    github.com/smallstep/logging.TestLogger_callerFormats"}},
+		{"common", []string{` caller="/`, "\n  stacktrace:\n    github.com/smallstep/logging.TestLogger_callerFormats"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var out bytes.Buffer
+			logger, err := New("test",
+				WithSink("out", &out, AllLevels(), tt.format),
+				WithCaller(&Caller{FullPath: true}),
+				WithStacktrace(WarnLevel),
+			)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			logger.Warn("", zap.String("method", "GET"))
+			for _, w := range tt.want {
+				if !strings.Contains(out.String(), w) {
+					t.Errorf("output %q does not contain %q", out.String(), w)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,414 @@
+package logging
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Config is the configuration of a logger. A Config can be decoded from the
+// JSON used by WithConfig, loaded from LOG_* environment variables using
+// LoadEnv, or bound to command line flags using RegisterFlags, and used to
+// create a logger with the FromConfig option.
+type Config struct {
+	// Format is the format of the entries: "text", "json", "common",
+	// "syslog", "journald", "gcp" or "ecs".
+	Format string `json:"format"`
+	// Level is the minimum level logged.
+	Level Level `json:"level"`
+	// Levels overrides the level for some logger names.
+	Levels map[string]Level `json:"levels"`
+	// TraceHeader is the header used for tracing.
+	TraceHeader string `json:"traceHeader"`
+	// LogRequests enables the log of requests.
+	LogRequests bool `json:"logRequests"`
+	// LogResponses enables the log of responses.
+	LogResponses bool `json:"logResponses"`
+	// TimeFormat is the format of the time fields.
+	TimeFormat string `json:"timeFormat"`
+	// Encoding configures the layout of the entries.
+	Encoding *Encoding `json:"encoding"`
+	// GCP configures the "gcp" format.
+	GCP *GCP `json:"gcp"`
+	// CallerSkip is the number of callers skipped by caller annotation.
+	CallerSkip int `json:"callerSkip"`
+	// Development enables the development mode, where the entries at dpanic
+	// level panic.
+	Development bool `json:"development"`
+	// Caller enables the annotation of the entries with the caller.
+	Caller *Caller `json:"caller"`
+	// StacktraceLevel enables the stack traces in the entries at or above
+	// the level.
+	StacktraceLevel *Level `json:"stacktraceLevel"`
+	// ErrorMaxDepth is the maximum depth of the causes rendered in the error
+	// fields. Defaults to 10.
+	ErrorMaxDepth int `json:"errorMaxDepth"`
+	// Service adds the fields describing the service to every entry.
+	Service *ServiceInfo `json:"service"`
+	// Fields are added to every entry.
+	Fields map[string]string `json:"fields"`
+	// Sinks are the outputs of the logger, stdout and stderr if empty.
+	Sinks []*Sink `json:"sinks"`
+	// Sampling configures the sampling of entries.
+	Sampling *Sampling `json:"sampling"`
+	// Async configures the asynchronous writing of entries.
+	Async *Async `json:"async"`
+	// Dedup configures the deduplication of repeated entries.
+	Dedup *Dedup `json:"dedup"`
+}
+
+// DefaultConfig returns the default configuration of a logger.
+func DefaultConfig() *Config {
+	return &Config{
+		Format:      "json",
+		Level:       InfoLevel,
+		TraceHeader: DefaultTraceHeader,
+		CallerSkip:  1,
+	}
+}
+
+// ValidationError is an error in a configuration field. Path is the JSON path
+// of the field, or the name of the environment variable.
+type ValidationError struct {
+	Path string
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors is a list of validation errors.
+type ValidationErrors []*ValidationError
+
+// Error implements the error interface.
+func (e ValidationErrors) Error() string {
+	s := make([]string, len(e))
+	for i, err := range e {
+		s[i] = err.Error()
+	}
+	return "invalid logger configuration: " + strings.Join(s, "; ")
+}
+
+// validator collects validation errors.
+type validator struct {
+	errs ValidationErrors
+}
+
+func (v *validator) add(path, format string, args ...interface{}) {
+	v.addError(path, fmt.Errorf(format, args...))
+}
+
+func (v *validator) addError(path string, err error) {
+	v.errs = append(v.errs, &ValidationError{Path: path, Err: err})
+}
+
+func (v *validator) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+// Validate validates the configuration. It returns a ValidationErrors with an
+// error for each invalid field.
+func (c *Config) Validate() error {
+	v := new(validator)
+	c.validate(v)
+	return v.err()
+}
+
+func (c *Config) validate(v *validator) {
+	if _, err := newEncoder(c.Format, zap.NewProductionEncoderConfig()); err != nil {
+		v.add("format", "unsupported format '%s'", c.Format)
+	}
+	if !c.Level.valid() {
+		v.add("level", "unknown level %s", c.Level)
+	}
+	validateLevels(v, "levels", c.Levels)
+	if c.StacktraceLevel != nil && !c.StacktraceLevel.valid() {
+		v.add("stacktraceLevel", "unknown level %s", *c.StacktraceLevel)
+	}
+	if c.CallerSkip < 0 {
+		v.add("callerSkip", "cannot be negative")
+	}
+	if c.ErrorMaxDepth < 0 {
+		v.add("errorMaxDepth", "cannot be negative")
+	}
+	c.validateFields(v)
+
+	names := make(map[string]bool, len(c.Sinks))
+	for i, s := range c.Sinks {
+		path := fmt.Sprintf("sinks[%d]", i)
+		if s == nil {
+			v.add(path, "cannot be null")
+			continue
+		}
+		name := s.name()
+		if names[name] {
+			v.add(path+".name", "duplicated sink '%s'", name)
+		}
+		names[name] = true
+		s.validate(v, path)
+	}
+
+	c.Encoding.validate(v, "encoding")
+	c.Sampling.validate(v, "sampling")
+	c.Async.validate(v, "async")
+	c.Dedup.validate(v, "dedup")
+}
+
+// validateFields validates the keys of the fields added to every entry, they
+// cannot be empty or replace the fields added by the service and the caller.
+func (c *Config) validateFields(v *validator) {
+	reserved := make(map[string]string)
+	if c.Service != nil {
+		for _, k := range serviceKeys {
+			reserved[k] = "service"
+		}
+	}
+	if c.Caller != nil {
+		var config zapcore.EncoderConfig
+		config.CallerKey = "caller"
+		c.Caller.encoderConfig(&config)
+		c.Encoding.encoderConfig(&config)
+		for _, k := range []string{config.CallerKey, config.FunctionKey} {
+			if k != "" {
+				reserved[k] = "caller"
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(c.Fields))
+	for k := range c.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if k == "" {
+			v.add("fields", "field key cannot be empty")
+		} else if s, ok := reserved[k]; ok {
+			v.add("fields."+k, "field is already added by the %s", s)
+		}
+	}
+}
+
+// LoadEnv sets the fields of the configuration defined in the environment.
+// The supported variables are LOG_FORMAT, LOG_LEVEL, LOG_LEVELS,
+// LOG_TRACE_HEADER, LOG_REQUESTS, LOG_RESPONSES, LOG_TIME_FORMAT,
+// LOG_ENCODING, LOG_GCP, LOG_CALLER_SKIP, LOG_DEVELOPMENT, LOG_CALLER,
+// LOG_STACKTRACE_LEVEL, LOG_ERROR_MAX_DEPTH, LOG_SERVICE, LOG_FIELDS,
+// LOG_SINKS, LOG_SAMPLING, LOG_ASYNC and LOG_DEDUP.
+// LOG_LEVELS uses the format "name=level,name=level", LOG_FIELDS uses the
+// format "key=value,key=value", and LOG_ENCODING, LOG_GCP, LOG_CALLER,
+// LOG_SERVICE, LOG_SINKS, LOG_SAMPLING, LOG_ASYNC and LOG_DEDUP use the JSON
+// format. It returns a ValidationErrors with an error for each invalid
+// variable.
+func (c *Config) LoadEnv() error {
+	v := new(validator)
+	for _, e := range c.vars() {
+		if s, ok := os.LookupEnv(e.env); ok {
+			if err := e.value.Set(s); err != nil {
+				v.addError(e.env, err)
+			}
+		}
+	}
+	return v.err()
+}
+
+// RegisterFlags registers a command line flag for each field in the
+// configuration, using the current values as defaults. The flags are
+// prefixed with "log-", for example, "log-format" or "log-level".
+func (c *Config) RegisterFlags(fs *flag.FlagSet) {
+	for _, e := range c.vars() {
+		fs.Var(e.value, e.flag, e.usage)
+	}
+}
+
+type configVar struct {
+	env   string
+	flag  string
+	usage string
+	value flag.Value
+}
+
+func (c *Config) vars() []configVar {
+	return []configVar{
+		{"LOG_FORMAT", "log-format", `the format of the logs: "text", "json", "common", "syslog", "journald", "gcp" or "ecs"`, (*stringValue)(&c.Format)},
+		{"LOG_LEVEL", "log-level", "the minimum level of the logs", (*levelValue)(&c.Level)},
+		{"LOG_LEVELS", "log-levels", `the levels by logger name, e.g. "authority.*=debug,grpc=warn"`, (*levelsValue)(&c.Levels)},
+		{"LOG_TRACE_HEADER", "log-trace-header", "the header used for tracing", (*stringValue)(&c.TraceHeader)},
+		{"LOG_REQUESTS", "log-requests", "log the requests", (*boolValue)(&c.LogRequests)},
+		{"LOG_RESPONSES", "log-responses", "log the responses", (*boolValue)(&c.LogResponses)},
+		{"LOG_TIME_FORMAT", "log-time-format", "the format of the time fields", (*stringValue)(&c.TimeFormat)},
+		{"LOG_ENCODING", "log-encoding", `the JSON layout of the entries, e.g. {"timeKey":"time","timeFormat":"rfc3339nano"}`, &jsonValue{&c.Encoding}},
+		{"LOG_GCP", "log-gcp", `the JSON configuration of the gcp format, e.g. {"projectId":"my-project"}`, &jsonValue{&c.GCP}},
+		{"LOG_CALLER_SKIP", "log-caller-skip", "the number of callers skipped by caller annotation", (*intValue)(&c.CallerSkip)},
+		{"LOG_DEVELOPMENT", "log-development", "enable the development mode, the logs at dpanic level panic", (*boolValue)(&c.Development)},
+		{"LOG_CALLER", "log-caller", `the JSON caller configuration, e.g. {"fullPath":true,"function":true}`, &jsonValue{&c.Caller}},
+		{"LOG_STACKTRACE_LEVEL", "log-stacktrace-level", "the minimum level of the logs with a stack trace", &optionalLevelValue{&c.StacktraceLevel}},
+		{"LOG_ERROR_MAX_DEPTH", "log-error-max-depth", "the maximum depth of the causes of the errors", (*intValue)(&c.ErrorMaxDepth)},
+		{"LOG_SERVICE", "log-service", "the JSON description of the service", &jsonValue{&c.Service}},
+		{"LOG_FIELDS", "log-fields", `the fields added to every entry, e.g. "team=pki,region=us"`, (*fieldsValue)(&c.Fields)},
+		{"LOG_SINKS", "log-sinks", "the JSON list of log sinks", &jsonValue{&c.Sinks}},
+		{"LOG_SAMPLING", "log-sampling", "the JSON sampling configuration", &jsonValue{&c.Sampling}},
+		{"LOG_ASYNC", "log-async", "the JSON asynchronous configuration", &jsonValue{&c.Async}},
+		{"LOG_DEDUP", "log-dedup", "the JSON deduplication configuration", &jsonValue{&c.Dedup}},
+	}
+}
+
+// The following types implement flag.Value for the fields of the Config.
+
+type stringValue string
+
+func (s *stringValue) Set(v string) error { *s = stringValue(v); return nil }
+func (s *stringValue) String() string     { return string(*s) }
+
+type boolValue bool
+
+func (b *boolValue) IsBoolFlag() bool { return true }
+func (b *boolValue) String() string   { return strconv.FormatBool(bool(*b)) }
+func (b *boolValue) Set(v string) error {
+	bb, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("invalid boolean %q", v)
+	}
+	*b = boolValue(bb)
+	return nil
+}
+
+type intValue int
+
+func (i *intValue) String() string { return strconv.Itoa(int(*i)) }
+func (i *intValue) Set(v string) error {
+	ii, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("invalid integer %q", v)
+	}
+	*i = intValue(ii)
+	return nil
+}
+
+type levelValue Level
+
+func (l *levelValue) String() string     { return Level(*l).String() }
+func (l *levelValue) Set(v string) error { return (*Level)(l).UnmarshalText([]byte(v)) }
+
+type optionalLevelValue struct {
+	level **Level
+}
+
+func (l *optionalLevelValue) String() string {
+	if l.level == nil || *l.level == nil {
+		return ""
+	}
+	return (*l.level).String()
+}
+
+func (l *optionalLevelValue) Set(v string) error {
+	level := new(Level)
+	if err := level.UnmarshalText([]byte(v)); err != nil {
+		return err
+	}
+	*l.level = level
+	return nil
+}
+
+type levelsValue map[string]Level
+
+func (l *levelsValue) String() string {
+	if l == nil {
+		return ""
+	}
+	s := make([]string, 0, len(*l))
+	for k, v := range *l {
+		s = append(s, k+"="+v.String())
+	}
+	sort.Strings(s)
+	return strings.Join(s, ",")
+}
+
+func (l *levelsValue) Set(v string) error {
+	m := make(map[string]Level)
+	for _, kv := range strings.Split(v, ",") {
+		if kv = strings.TrimSpace(kv); kv == "" {
+			continue
+		}
+		name, lvl, ok := strings.Cut(kv, "=")
+		if !ok {
+			return fmt.Errorf("invalid level %q, expected name=level", kv)
+		}
+		var level Level
+		if err := level.UnmarshalText([]byte(strings.TrimSpace(lvl))); err != nil {
+			return err
+		}
+		m[strings.TrimSpace(name)] = level
+	}
+	*l = m
+	return nil
+}
+
+type jsonValue struct {
+	v interface{}
+}
+
+func (j *jsonValue) String() string {
+	if j == nil || j.v == nil {
+		return ""
+	}
+	b, err := json.Marshal(j.v)
+	if err != nil || string(b) == "null" {
+		return ""
+	}
+	return string(b)
+}
+
+func (j *jsonValue) Set(v string) error {
+	if err := json.Unmarshal([]byte(v), j.v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return nil
+}
+
+type fieldsValue map[string]string
+
+func (f *fieldsValue) String() string {
+	if f == nil {
+		return ""
+	}
+	s := make([]string, 0, len(*f))
+	for k, v := range *f {
+		s = append(s, k+"="+v)
+	}
+	sort.Strings(s)
+	return strings.Join(s, ",")
+}
+
+func (f *fieldsValue) Set(v string) error {
+	m := make(map[string]string)
+	for _, kv := range strings.Split(v, ",") {
+		if kv = strings.TrimSpace(kv); kv == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			return fmt.Errorf("invalid field %q, expected key=value", kv)
+		}
+		m[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	*f = m
+	return nil
+}
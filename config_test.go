@@ -0,0 +1,102 @@
+package logging
+
+import (
+	"errors"
+	"flag"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	c := DefaultConfig()
+	c.Format = "foo"
+	c.Level = Level(42)
+	c.Levels = map[string]Level{"authority": Level(-3)}
+	c.StacktraceLevel = new(Level)
+	*c.StacktraceLevel = Level(8)
+	c.CallerSkip = -1
+	c.Caller = &Caller{Function: true}
+	c.Service = &ServiceInfo{Name: "ca"}
+	c.Fields = map[string]string{"service": "other", "function": "main", "team": "pki"}
+	c.Sinks = []*Sink{
+		{Name: "file", Output: "stdout", Levels: LevelsBetween(ErrorLevel, InfoLevel), Rotation: &Rotation{MaxSize: -1}},
+		{Name: "file", Output: "/tmp/file.log", Format: "bar"},
+	}
+	c.Sampling = &Sampling{Levels: map[Level]SamplingPolicy{WarnLevel: {Initial: -1}}}
+	c.Async = &Async{Policy: "foo"}
+
+	var errs ValidationErrors
+	if err := c.Validate(); !errors.As(err, &errs) {
+		t.Fatalf("Config.Validate() error = %v, want ValidationErrors", err)
+	}
+
+	var paths []string
+	for _, e := range errs {
+		paths = append(paths, e.Path)
+	}
+	want := []string{
+		"format", "level", "levels.authority", "stacktraceLevel", "callerSkip",
+		"fields.function", "fields.service",
+		"sinks[0].levels", "sinks[0].rotation", "sinks[0].rotation.maxSize",
+		"sinks[1].name", "sinks[1].format",
+		"sampling.levels.warn.initial", "async.policy",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("Config.Validate() paths = %v, want %v", paths, want)
+	}
+
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Errorf("Config.Validate() error = %v", err)
+	}
+}
+
+func TestConfig_LoadEnv(t *testing.T) {
+	t.Setenv("LOG_FORMAT", "text")
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("LOG_LEVELS", "authority.*=warn, grpc=error")
+	t.Setenv("LOG_REQUESTS", "true")
+	t.Setenv("LOG_CALLER_SKIP", "2")
+	t.Setenv("LOG_SAMPLING", `{"initial":10,"interval":"1m"}`)
+
+	c := DefaultConfig()
+	if err := c.LoadEnv(); err != nil {
+		t.Fatalf("Config.LoadEnv() error = %v", err)
+	}
+	want := DefaultConfig()
+	want.Format = "text"
+	want.Level = DebugLevel
+	want.Levels = map[string]Level{"authority.*": WarnLevel, "grpc": ErrorLevel}
+	want.LogRequests = true
+	want.CallerSkip = 2
+	want.Sampling = &Sampling{SamplingPolicy: SamplingPolicy{Initial: 10}, Interval: Duration{time.Minute}}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("Config.LoadEnv() = %+v, want %+v", c, want)
+	}
+
+	t.Setenv("LOG_LEVEL", "foo")
+	t.Setenv("LOG_RESPONSES", "foo")
+	var errs ValidationErrors
+	if err := c.LoadEnv(); !errors.As(err, &errs) || len(errs) != 2 || errs[0].Path != "LOG_LEVEL" || errs[1].Path != "LOG_RESPONSES" {
+		t.Errorf("Config.LoadEnv() error = %v", err)
+	}
+}
+
+func TestConfig_RegisterFlags(t *testing.T) {
+	c := DefaultConfig()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	c.RegisterFlags(fs)
+
+	if err := fs.Parse([]string{"-log-level", "warn", "-log-responses", "-log-async", `{"size":10}`, "-log-levels", "grpc=warn,authority=debug,acme=trace"}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Level != WarnLevel || !c.LogResponses || c.Async == nil || c.Async.Size != 10 {
+		t.Errorf("unexpected config %+v", c)
+	}
+	if f := fs.Lookup("log-format"); f == nil || f.DefValue != "json" {
+		t.Errorf("unexpected log-format flag %+v", f)
+	}
+	if s := fs.Lookup("log-levels").Value.String(); s != "acme=trace,authority=debug,grpc=warn" {
+		t.Errorf("log-levels = %q, want %q", s, "acme=trace,authority=debug,grpc=warn")
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/smallstep/logging/requestid"
 	"github.com/smallstep/logging/tracing"
+	"go.uber.org/zap"
 )
 
 type key int
@@ -72,3 +74,38 @@ func GetName(ctx context.Context) (string, bool) {
 	v, ok := ctx.Value(nameKey).(string)
 	return v, ok
 }
+
+// ContextFields returns the fields used to correlate a log entry with a
+// request: the name, the request-id, the tracing-id and the trace-id and
+// span-id of the traceparent. The name defaults to the given one if the
+// context does not define it, and the request-id defaults to the trace-id.
+func ContextFields(ctx context.Context, name string) []zap.Field {
+	if ctx == nil {
+		return []zap.Field{zap.String("name", name)}
+	}
+
+	if s, ok := GetName(ctx); ok {
+		name = s
+	}
+	requestID := requestid.FromContext(ctx)
+
+	tp, ok := GetTraceparent(ctx)
+	if !ok {
+		fields := []zap.Field{zap.String("name", name)}
+		if requestID != "" {
+			fields = append(fields, zap.String("request-id", requestID))
+		}
+		return fields
+	}
+
+	if requestID == "" {
+		requestID = tp.TraceID()
+	}
+	return []zap.Field{
+		zap.String("name", name),
+		zap.String("request-id", requestID),
+		zap.String("tracing-id", tp.String()),
+		zap.String("trace-id", tp.TraceID()),
+		zap.String("span-id", tp.SpanID()),
+	}
+}
@@ -0,0 +1,120 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/smallstep/logging/requestid"
+	"github.com/smallstep/logging/tracing"
+	"go.uber.org/zap"
+)
+
+const testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestContextFields(t *testing.T) {
+	tp, err := tracing.Parse(testTraceparent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want map[string]string
+	}{
+		{"nil", nil, map[string]string{"name": "app"}},
+		{"empty", context.Background(), map[string]string{"name": "app"}},
+		{"name", WithName(context.Background(), "ctx-name"), map[string]string{"name": "ctx-name"}},
+		{"request-id", requestid.NewContext(context.Background(), "req-1"), map[string]string{
+			"name":       "app",
+			"request-id": "req-1",
+		}},
+		{"traceparent", WithTraceparent(context.Background(), tp), map[string]string{
+			"name":       "app",
+			"request-id": "4bf92f3577b34da6a3ce929d0e0e4736",
+			"tracing-id": testTraceparent,
+			"trace-id":   "4bf92f3577b34da6a3ce929d0e0e4736",
+			"span-id":    "00f067aa0ba902b7",
+		}},
+		{"traceparent and request-id", requestid.NewContext(WithTraceparent(context.Background(), tp), "req-1"), map[string]string{
+			"name":       "app",
+			"request-id": "req-1",
+			"tracing-id": testTraceparent,
+			"trace-id":   "4bf92f3577b34da6a3ce929d0e0e4736",
+			"span-id":    "00f067aa0ba902b7",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := ContextFields(tt.ctx, "app")
+			got := make(map[string]string, len(fields))
+			for _, f := range fields {
+				got[f.Key] = f.String
+			}
+			if len(got) != len(tt.want) || len(fields) != len(tt.want) {
+				t.Fatalf("ContextFields() = %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("ContextFields()[%q] = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestLogger_WithContext(t *testing.T) {
+	tp, err := tracing.Parse(testTraceparent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithName(WithTraceparent(context.Background(), tp), "ctx-name")
+
+	var buf bytes.Buffer
+	logger, err := New("app", WithSink("json", &buf, AllLevels(), "json"), WithLogLevel(TraceLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.InfoContext(ctx, "info context")
+	logger.WithContext(ctx).Info("with context")
+	logger.WithContext(ctx).InfoContext(ctx, "both", zap.String("key", "value"))
+	logger.WithContext(ctx).TraceContext(ctx, "trace context")
+	logger.WithContext(ctx).DPanicContext(ctx, "dpanic context")
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("PanicContext() did not panic")
+			}
+		}()
+		logger.WithContext(ctx).PanicContext(ctx, "panic context")
+	}()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("got %d entries, want 6: %s", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		for _, key := range []string{"name", "request-id", "tracing-id", "trace-id", "span-id"} {
+			if n := strings.Count(line, `"`+key+`":`); n != 1 {
+				t.Errorf("entry has %d %q fields: %s", n, key, line)
+			}
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry["name"] != "ctx-name" || entry["tracing-id"] != testTraceparent || entry["span-id"] != "00f067aa0ba902b7" {
+			t.Errorf("unexpected entry: %s", line)
+		}
+	}
+	if !strings.Contains(lines[2], `"key":"value"`) {
+		t.Errorf("entry does not have the extra field: %s", lines[2])
+	}
+	if !strings.Contains(lines[3], `"level":"trace"`) || !strings.Contains(lines[4], `"level":"dpanic"`) || !strings.Contains(lines[5], `"level":"panic"`) {
+		t.Errorf("unexpected levels: %s", buf.String())
+	}
+}
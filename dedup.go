@@ -0,0 +1,275 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultDedupWindow is the window used if none is configured.
+const defaultDedupWindow = 10 * time.Second
+
+// minDedupWindow is the minimum window, the records are flushed every half
+// window.
+const minDedupWindow = time.Millisecond
+
+// Dedup configures the deduplication of entries. Entries with the same level,
+// message and values of the key fields are collapsed within a window: the
+// first one is written immediately, and when the window ends, if there were
+// repetitions, a summary entry with the same level, message and fields is
+// written with the number of repetitions in the "repeated" field and the time
+// of the first and last repetitions in the "first" and "last" fields.
+type Dedup struct {
+	// Window is the period in which repeated entries are collapsed. It must
+	// be at least 1ms. Defaults to 10s.
+	Window Duration `json:"window"`
+	// Fields are the keys of the fields that, with the level and message,
+	// identify repeated entries. Other fields are ignored.
+	Fields []string `json:"fields"`
+}
+
+// Validate validates the deduplication configuration.
+func (d *Dedup) Validate() error {
+	v := new(validator)
+	d.validate(v, "dedup")
+	return v.err()
+}
+
+func (d *Dedup) validate(v *validator, path string) {
+	if d == nil {
+		return
+	}
+	switch w := d.Window.Duration; {
+	case w < 0:
+		v.add(path+".window", "cannot be negative")
+	case w > 0 && w < minDedupWindow:
+		v.add(path+".window", "must be at least %s", minDedupWindow)
+	}
+	for i, f := range d.Fields {
+		if f == "" {
+			v.add(fmt.Sprintf("%s.fields[%d]", path, i), "cannot be empty")
+		}
+	}
+}
+
+func (d *Dedup) window() time.Duration {
+	if d.Window.Duration == 0 {
+		return defaultDedupWindow
+	}
+	return d.Window.Duration
+}
+
+// dedupRecord is an entry written in the current window and its repetitions.
+type dedupRecord struct {
+	core     *dedupCore
+	ent      zapcore.Entry
+	fields   []zapcore.Field
+	last     time.Time
+	repeated int
+}
+
+// dedupState keeps the entries written in the current window, it is shared
+// by all the cores of a logger.
+type dedupState struct {
+	mu       sync.Mutex
+	window   time.Duration
+	fields   []string
+	keys     map[string]bool
+	records  map[string]*dedupRecord
+	now      func() time.Time
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	stopOnce sync.Once
+}
+
+func newDedupState(d *Dedup, now func() time.Time) *dedupState {
+	var fields []string
+	keys := make(map[string]bool, len(d.Fields))
+	for _, f := range d.Fields {
+		if !keys[f] {
+			keys[f] = true
+			fields = append(fields, f)
+		}
+	}
+	s := &dedupState{
+		window:  d.window(),
+		fields:  fields,
+		keys:    keys,
+		records: make(map[string]*dedupRecord),
+		now:     now,
+		stopCh:  make(chan struct{}),
+		doneCh:  make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+func (s *dedupState) run() {
+	defer close(s.doneCh)
+	ticker := time.NewTicker(s.window / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.flush(false)
+		case <-s.stopCh:
+			s.flush(true)
+			return
+		}
+	}
+}
+
+// flush writes the summary of the records with an expired window, or all the
+// records if all is true.
+func (s *dedupState) flush(all bool) {
+	now := s.now()
+	var expired []*dedupRecord
+	s.mu.Lock()
+	for k, r := range s.records {
+		if all || !now.Before(r.ent.Time.Add(s.window)) {
+			expired = append(expired, r)
+			delete(s.records, k)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, r := range expired {
+		r.summarize()
+	}
+}
+
+// Close writes the summary of all the records and stops the background
+// goroutine.
+func (s *dedupState) Close() error {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	<-s.doneCh
+	return nil
+}
+
+// summarize writes the summary entry if the record has repetitions.
+func (r *dedupRecord) summarize() {
+	if r.repeated == 0 {
+		return
+	}
+	ent := r.ent
+	ent.Time = r.last
+	fields := append(r.fields[:len(r.fields):len(r.fields)],
+		zap.Int("repeated", r.repeated),
+		zap.Time("first", r.ent.Time),
+		zap.Time("last", r.last),
+	)
+	r.core.write(ent, fields)
+}
+
+// dedupCore is a zapcore.Core that collapses repeated entries.
+type dedupCore struct {
+	zapcore.Core
+	state *dedupState
+	// context contains the key fields added with With.
+	context []zapcore.Field
+}
+
+func newDedupCore(core zapcore.Core, state *dedupState) *dedupCore {
+	return &dedupCore{
+		Core:  core,
+		state: state,
+	}
+}
+
+// With adds structured context to the core.
+func (c *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	context := c.context[:len(c.context):len(c.context)]
+	for _, f := range fields {
+		if c.state.keys[f.Key] {
+			context = append(context, f)
+		}
+	}
+	return &dedupCore{
+		Core:    c.Core.With(fields),
+		state:   c.state,
+		context: context,
+	}
+}
+
+// Check determines whether the supplied Entry should be logged. Panic and
+// fatal entries are never collapsed.
+func (c *dedupCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level > zapcore.ErrorLevel {
+		return c.Core.Check(ent, ce)
+	}
+	if c.Core.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write writes the entry if it's not a repetition of an entry in the current
+// window.
+func (c *dedupCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	key := c.key(ent, fields)
+
+	s := c.state
+	s.mu.Lock()
+	r, ok := s.records[key]
+	if ok && ent.Time.Before(r.ent.Time.Add(s.window)) {
+		r.repeated++
+		r.last = ent.Time
+		s.mu.Unlock()
+		return nil
+	}
+	s.records[key] = &dedupRecord{
+		core:   c,
+		ent:    ent,
+		fields: append([]zapcore.Field(nil), fields...),
+		last:   ent.Time,
+	}
+	s.mu.Unlock()
+
+	if ok {
+		r.summarize()
+	}
+	return c.write(ent, fields)
+}
+
+// write writes the entry to the underlying core.
+func (c *dedupCore) write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if ce := c.Core.Check(ent, nil); ce != nil {
+		ce.Write(fields...)
+	}
+	return nil
+}
+
+// key returns the key that identifies repeated entries.
+func (c *dedupCore) key(ent zapcore.Entry, fields []zapcore.Field) string {
+	var b strings.Builder
+	b.WriteString(ent.LoggerName)
+	b.WriteByte(0)
+	b.WriteString(ent.Level.String())
+	b.WriteByte(0)
+	b.WriteString(ent.Message)
+	if len(c.state.keys) == 0 {
+		return b.String()
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.context {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		if c.state.keys[f.Key] {
+			f.AddTo(enc)
+		}
+	}
+	for _, k := range c.state.fields {
+		if v, ok := enc.Fields[k]; ok {
+			fmt.Fprintf(&b, "\x00%s=%v", k, v)
+		}
+	}
+	return b.String()
+}
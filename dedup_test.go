@@ -0,0 +1,109 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smallstep/logging/internal/testclock"
+	"go.uber.org/zap"
+)
+
+func TestLogger_dedup(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := testclock.New(start)
+
+	var out bytes.Buffer
+	logger, err := New("test",
+		WithSink("out", &out, AllLevels(), "json"),
+		WithClock(clock),
+		WithDedup(&Dedup{Window: Duration{time.Minute}, Fields: []string{"user"}}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		logger.Warn("repeated", zap.String("user", "alice"), zap.Int("i", i))
+		clock.Add(time.Second)
+	}
+	logger.Warn("repeated", zap.String("user", "bob"))
+	logger.Error("repeated", zap.String("user", "alice"))
+	logger.With(zap.String("user", "alice")).Warn("repeated")
+
+	// A new window writes the summary and the new entry.
+	clock.Add(time.Minute)
+	logger.Warn("repeated", zap.String("user", "bob"))
+
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Logger.Close() error = %v", err)
+	}
+
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v", err)
+		}
+		entries = append(entries, m)
+	}
+
+	type result struct {
+		level, user string
+		repeated    float64
+	}
+	var got []result
+	for _, e := range entries {
+		r := result{level: e["level"].(string), user: e["user"].(string)}
+		if n, ok := e["repeated"].(float64); ok {
+			r.repeated = n
+		}
+		got = append(got, r)
+	}
+	want := []result{
+		{"warn", "alice", 0},
+		{"warn", "bob", 0},
+		{"error", "alice", 0},
+		{"warn", "bob", 0},
+		{"warn", "alice", 3},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries, want %d: %s", len(got), len(want), out.String())
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	summary := entries[4]
+	if summary["i"] != float64(0) {
+		t.Errorf("summary i = %v, want 0", summary["i"])
+	}
+	if summary["first"] == nil || summary["first"] == summary["last"] {
+		t.Errorf("summary first = %v, last = %v", summary["first"], summary["last"])
+	}
+}
+
+func TestDedup_Validate(t *testing.T) {
+	if err := (&Dedup{Window: Duration{-time.Second}, Fields: []string{""}}).Validate(); err == nil {
+		t.Error("Dedup.Validate() error = nil, want error")
+	}
+	if err := (&Dedup{}).Validate(); err != nil {
+		t.Errorf("Dedup.Validate() error = %v", err)
+	}
+	// The records are flushed every half window.
+	for _, w := range []time.Duration{time.Nanosecond, time.Millisecond - 1} {
+		if err := (&Dedup{Window: Duration{w}}).Validate(); err == nil {
+			t.Errorf("Dedup.Validate() with window %s error = nil, want error", w)
+		}
+		if _, err := New("test", WithDedup(&Dedup{Window: Duration{w}})); err == nil {
+			t.Errorf("New() with window %s error = nil, want error", w)
+		}
+	}
+	if err := (&Dedup{Window: Duration{time.Millisecond}}).Validate(); err != nil {
+		t.Errorf("Dedup.Validate() error = %v", err)
+	}
+}
@@ -30,6 +30,14 @@ func init() {
 // NewCLFEncoder returns a new encoder that logs messages with the Common Log
 // Format. Each logged line will follow the format:
 // <request-id> <remote-address> <name> <user-id> <time> <duration> "<method> <path> <protocol>" <status> <size>
+//
+// Other fields added to the logger with With or to the entry, and the caller
+// if enabled, are appended to the line using the format key="value". The
+// fields of the entries written by the HTTP and gRPC loggers, the ones with a
+// "system" field, are not appended if they are not part of the format. The
+// stack trace is written as an indented block after the line. Time and
+// duration fields use the EncodeTime and EncodeDuration of the configuration,
+// or RFC 3339 and milliseconds if they are not set.
 func NewCLFEncoder(config zapcore.EncoderConfig) zapcore.Encoder {
 	return &clfEncoder{
 		EncoderConfig: &config,
@@ -40,6 +48,10 @@ func NewCLFEncoder(config zapcore.EncoderConfig) zapcore.Encoder {
 type clfEncoder struct {
 	*zapcore.EncoderConfig
 	data []string
+	// extra contains the fields that are not part of the format, they are
+	// ignored in the fields of the HTTP and gRPC entries.
+	extra       []string
+	ignoreExtra bool
 }
 
 // Clone copies the encoder, ensuring that adding fields to the copy doesn't
@@ -50,15 +62,28 @@ func (e *clfEncoder) Clone() zapcore.Encoder {
 	return &clfEncoder{
 		EncoderConfig: e.EncoderConfig,
 		data:          data,
+		extra:         e.extra[:len(e.extra):len(e.extra)],
 	}
 }
 
-func (e *clfEncoder) clone() *clfEncoder {
+func (e *clfEncoder) clone(ignoreExtra bool) *clfEncoder {
 	data := make([]string, len(clfFieldsMap))
 	copy(data, clfFieldsEmpty)
 	return &clfEncoder{
 		EncoderConfig: e.EncoderConfig,
 		data:          data,
+		extra:         e.extra[:len(e.extra):len(e.extra)],
+		ignoreExtra:   ignoreExtra,
+	}
+}
+
+// set sets the value of a field of the format, or adds it to the extra
+// fields.
+func (e *clfEncoder) set(key, value string) {
+	if i, ok := clfFieldsMap[key]; ok {
+		e.data[i] = value
+	} else if !e.ignoreExtra {
+		e.extra = append(e.extra, key+"="+strconv.Quote(value))
 	}
 }
 
@@ -66,13 +91,16 @@ func (e *clfEncoder) clone() *clfEncoder {
 // into a byte buffer and returns it. Any fields that are empty, including
 // fields on the `Entry` type, should be omitted.
 func (e *clfEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
-	final := e.clone()
+	final := e.clone(entrySystem(fields) != "")
 
 	buf := pool.Get()
 	if entry.Message != "" {
 		buf.AppendString(entry.Message + "\n")
 	}
 	if len(fields) == 0 {
+		if entry.Stack != "" && e.StacktraceKey != "" {
+			appendBlock(buf, e.StacktraceKey, entry.Stack)
+		}
 		return buf, nil
 	}
 
@@ -101,7 +129,22 @@ func (e *clfEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*
 	buf.AppendString(final.data[9])
 	buf.AppendByte(' ')
 	buf.AppendString(final.data[10])
+	for _, s := range final.extra {
+		buf.AppendByte(' ')
+		buf.AppendString(s)
+	}
+	if entry.Caller.Defined {
+		if e.CallerKey != "" {
+			buf.AppendString(" " + e.CallerKey + "=" + strconv.Quote(formatCaller(e.EncoderConfig, entry.Caller)))
+		}
+		if e.FunctionKey != "" && entry.Caller.Function != "" {
+			buf.AppendString(" " + e.FunctionKey + "=" + strconv.Quote(entry.Caller.Function))
+		}
+	}
 	buf.AppendByte('\n')
+	if entry.Stack != "" && e.StacktraceKey != "" {
+		appendBlock(buf, e.StacktraceKey, entry.Stack)
+	}
 	return buf, nil
 }
 
@@ -114,34 +157,24 @@ func (e *clfEncoder) AddObject(string, zapcore.ObjectMarshaler) error {
 }
 
 func (e *clfEncoder) AddBinary(key string, value []byte) { // for arbitrary bytes
-	if i, ok := clfFieldsMap[key]; ok {
-		e.data[i] = base64.StdEncoding.EncodeToString(value)
-	}
+	e.set(key, base64.StdEncoding.EncodeToString(value))
 }
 
 func (e *clfEncoder) AddByteString(key string, value []byte) { // for UTF-8 encoded bytes
-	if i, ok := clfFieldsMap[key]; ok {
-		e.data[i] = string(value)
-	}
+	e.set(key, string(value))
 }
 
 func (e *clfEncoder) AddString(key, value string) {
-	if i, ok := clfFieldsMap[key]; ok {
-		e.data[i] = value
-	}
+	e.set(key, value)
 }
 
 func (e *clfEncoder) AddBool(key string, value bool) {
-	if i, ok := clfFieldsMap[key]; ok {
-		e.data[i] = strconv.FormatBool(value)
-	}
+	e.set(key, strconv.FormatBool(value))
 }
 
 func (e *clfEncoder) AddComplex128(key string, value complex128) {
-	if i, ok := clfFieldsMap[key]; ok {
-		r, img := real(value), imag(value)
-		e.data[i] = fmt.Sprintf(`"%s+%si"`, strconv.FormatFloat(r, 'f', -1, 64), strconv.FormatFloat(img, 'f', -1, 64))
-	}
+	r, img := real(value), imag(value)
+	e.set(key, fmt.Sprintf(`"%s+%si"`, strconv.FormatFloat(r, 'f', -1, 64), strconv.FormatFloat(img, 'f', -1, 64)))
 }
 
 func (e *clfEncoder) AddComplex64(key string, value complex64) {
@@ -149,41 +182,39 @@ func (e *clfEncoder) AddComplex64(key string, value complex64) {
 }
 
 func (e *clfEncoder) AddDuration(key string, value time.Duration) {
-	if i, ok := clfFieldsMap[key]; ok {
-		e.data[i] = strconv.FormatInt(value.Milliseconds(), 10)
+	if e.EncodeDuration != nil {
+		e.set(key, formatDuration(e.EncoderConfig, value))
+	} else {
+		e.set(key, strconv.FormatInt(value.Milliseconds(), 10))
 	}
 }
 
 func (e *clfEncoder) AddFloat64(key string, value float64) {
-	if i, ok := clfFieldsMap[key]; ok {
-		switch {
-		case math.IsNaN(value):
-			e.data[i] = "NaN"
-		case math.IsInf(value, 1):
-			e.data[i] = "+Inf"
-		case math.IsInf(value, -1):
-			e.data[i] = "-Inf"
-		default:
-			e.data[i] = strconv.FormatFloat(value, 'f', -1, 64)
-		}
+	switch {
+	case math.IsNaN(value):
+		e.set(key, "NaN")
+	case math.IsInf(value, 1):
+		e.set(key, "+Inf")
+	case math.IsInf(value, -1):
+		e.set(key, "-Inf")
+	default:
+		e.set(key, strconv.FormatFloat(value, 'f', -1, 64))
 	}
 }
 
 func (e *clfEncoder) AddInt64(key string, value int64) {
-	if i, ok := clfFieldsMap[key]; ok {
-		e.data[i] = strconv.FormatInt(value, 10)
-	}
+	e.set(key, strconv.FormatInt(value, 10))
 }
 
 func (e *clfEncoder) AddUint64(key string, value uint64) {
-	if i, ok := clfFieldsMap[key]; ok {
-		e.data[i] = strconv.FormatUint(value, 10)
-	}
+	e.set(key, strconv.FormatUint(value, 10))
 }
 
 func (e *clfEncoder) AddTime(key string, value time.Time) {
-	if i, ok := clfFieldsMap[key]; ok {
-		e.data[i] = value.Format(time.RFC3339)
+	if e.EncodeTime != nil {
+		e.set(key, formatTime(e.EncoderConfig, value))
+	} else {
+		e.set(key, value.Format(time.RFC3339))
 	}
 }
 
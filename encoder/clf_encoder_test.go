@@ -0,0 +1,44 @@
+package encoder
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestCLFEncoder(t *testing.T) {
+	enc := NewCLFEncoder(zap.NewProductionEncoderConfig())
+	enc.AddString("team", "pki")
+
+	tests := []struct {
+		name   string
+		fields []zapcore.Field
+		want   string
+	}{
+		{"http", httpFields(), `4bf92f3577b34da6a3ce929d0e0e4736 192.0.2.1 test - - 0.25 "GET /foo?bar=baz HTTP/1.1" 404 0 team="pki"` + "\n"},
+		{"fields", []zapcore.Field{zap.String("user", "max"), zap.Int("attempt", 2)}, `- - - - - - "- - -" - - team="pki" user="max" attempt="2"` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf, err := enc.EncodeEntry(zapcore.Entry{Time: testTime}, tt.fields)
+			if err != nil {
+				t.Fatalf("EncodeEntry() error = %v", err)
+			}
+			defer buf.Free()
+			if got := buf.String(); got != tt.want {
+				t.Errorf("EncodeEntry() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// The fields of an entry are not added to the next ones.
+	buf, err := enc.EncodeEntry(zapcore.Entry{}, []zapcore.Field{zap.String("method", "GET")})
+	if err != nil {
+		t.Fatalf("EncodeEntry() error = %v", err)
+	}
+	defer buf.Free()
+	if want := `- - - - - - "GET - -" - - team="pki"` + "\n"; buf.String() != want {
+		t.Errorf("EncodeEntry() = %q, want %q", buf.String(), want)
+	}
+}
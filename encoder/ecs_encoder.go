@@ -0,0 +1,297 @@
+package encoder
+
+import (
+	"bytes"
+	"strings"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/smallstep/logging/tracing"
+)
+
+// ECSVersion is the version of the Elastic Common Schema used by the ECS
+// encoder.
+const ECSVersion = "8.11.0"
+
+// ecsFieldKeys are the keys of the fields added by the logger and their ECS
+// equivalents.
+var ecsFieldKeys = map[string]string{
+	"request-id":    "http.request.id",
+	"version":       "service.version",
+	"environment":   "service.environment",
+	"hostname":      "host.hostname",
+	"pid":           "process.pid",
+	"k8s.pod":       "kubernetes.pod.name",
+	"k8s.namespace": "kubernetes.namespace",
+	"k8s.node":      "kubernetes.node.name",
+}
+
+// ecsGRPCCodes maps the names of the gRPC codes to their values.
+var ecsGRPCCodes = map[string]int64{
+	"OK":                 0,
+	"Canceled":           1,
+	"Unknown":            2,
+	"InvalidArgument":    3,
+	"DeadlineExceeded":   4,
+	"NotFound":           5,
+	"AlreadyExists":      6,
+	"PermissionDenied":   7,
+	"ResourceExhausted":  8,
+	"FailedPrecondition": 9,
+	"Aborted":            10,
+	"OutOfRange":         11,
+	"Unimplemented":      12,
+	"Internal":           13,
+	"Unavailable":        14,
+	"DataLoss":           15,
+	"Unauthenticated":    16,
+}
+
+// ECSConfig contains the values used in the ECS documents.
+type ECSConfig struct {
+	// ServiceName is the service.name used if the entry does not have a
+	// logger name.
+	ServiceName string
+}
+
+// NewECSEncoder returns a new JSON encoder that logs messages using the
+// Elastic Common Schema. Each entry starts with "@timestamp", "log.level",
+// "message" and "ecs.version", in that order, followed by the fields, and
+// sets "service.name" using the logger name, and "log.origin.file.name",
+// "log.origin.file.line" and "log.origin.function" if the caller is enabled.
+//
+// The "tracing-id" fields are converted to the "trace.id" and "span.id"
+// fields, replacing the "trace-id" and "span-id" fields. The fields added by
+// the logger, like "request-id" or the service fields, use their ECS names,
+// and if a "service" field is set it is used as "service.name" and the logger
+// name is written in "log.logger". The fields of the entries written by the
+// HTTP and gRPC loggers are converted to the ECS and OpenTelemetry fields,
+// like "http.request.method", "url.path", "rpc.service" or "event.duration".
+func NewECSEncoder(config zapcore.EncoderConfig, ec ECSConfig) zapcore.Encoder {
+	config.TimeKey = "@timestamp"
+	config.EncodeTime = zapcore.ISO8601TimeEncoder
+	config.LevelKey = "log.level"
+	if config.EncodeLevel == nil {
+		config.EncodeLevel = zapcore.LowercaseLevelEncoder
+	}
+	config.MessageKey = "message"
+	config.NameKey = zapcore.OmitKey
+	config.CallerKey = zapcore.OmitKey
+	config.FunctionKey = zapcore.OmitKey
+	config.StacktraceKey = "error.stack_trace"
+
+	// The keys of the entry are written by the header encoder, so they are
+	// before the context and the fields.
+	header := config
+	header.TimeKey = zapcore.OmitKey
+	header.LevelKey = zapcore.OmitKey
+	header.MessageKey = zapcore.OmitKey
+	header.StacktraceKey = zapcore.OmitKey
+	body := header
+	body.StacktraceKey = config.StacktraceKey
+	return &ecsEncoder{
+		Encoder:     zapcore.NewJSONEncoder(body),
+		header:      zapcore.NewJSONEncoder(header),
+		config:      config,
+		serviceName: ec.ServiceName,
+	}
+}
+
+type ecsEncoder struct {
+	zapcore.Encoder
+	header      zapcore.Encoder
+	config      zapcore.EncoderConfig
+	serviceName string
+	// service is true if the service name is set by a field.
+	service bool
+}
+
+// Clone copies the encoder, ensuring that adding fields to the copy doesn't
+// affect the original.
+func (e *ecsEncoder) Clone() zapcore.Encoder {
+	return &ecsEncoder{
+		Encoder:     e.Encoder.Clone(),
+		header:      e.header,
+		config:      e.config,
+		serviceName: e.serviceName,
+		service:     e.service,
+	}
+}
+
+// AddString adds a string field using its ECS name, converting the
+// tracing-id to the trace.id and span.id fields.
+func (e *ecsEncoder) AddString(key, value string) {
+	switch key {
+	case "service":
+		e.service = true
+		e.Encoder.AddString("service.name", value)
+	case "tracing-id":
+		if tp, err := tracing.Parse(value); err == nil {
+			e.Encoder.AddString("trace.id", tp.TraceID())
+			e.Encoder.AddString("span.id", tp.SpanID())
+		}
+	case "trace-id", "span-id":
+	default:
+		if k, ok := ecsFieldKeys[key]; ok {
+			key = k
+		}
+		e.Encoder.AddString(key, value)
+	}
+}
+
+// AddInt64 adds an int64 field using its ECS name.
+func (e *ecsEncoder) AddInt64(key string, value int64) {
+	if k, ok := ecsFieldKeys[key]; ok {
+		key = k
+	}
+	e.Encoder.AddInt64(key, value)
+}
+
+// EncodeEntry encodes an entry and fields, along with any accumulated context,
+// into a byte buffer and returns it.
+func (e *ecsEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final := e.Clone().(*ecsEncoder)
+
+	switch entrySystem(fields) {
+	case "http":
+		fields = ecsHTTPFields(fields)
+	case "grpc":
+		fields = ecsGRPCFields(fields)
+	}
+	for i := range fields {
+		fields[i].AddTo(final)
+	}
+
+	switch {
+	case final.service:
+		if entry.LoggerName != "" {
+			final.Encoder.AddString("log.logger", entry.LoggerName)
+		}
+	case entry.LoggerName != "":
+		final.Encoder.AddString("service.name", entry.LoggerName)
+	case e.serviceName != "":
+		final.Encoder.AddString("service.name", e.serviceName)
+	}
+	if entry.Caller.Defined {
+		final.Encoder.AddString("log.origin.file.name", entry.Caller.File)
+		final.Encoder.AddInt("log.origin.file.line", entry.Caller.Line)
+		if entry.Caller.Function != "" {
+			final.Encoder.AddString("log.origin.function", entry.Caller.Function)
+		}
+	}
+	body, err := final.Encoder.EncodeEntry(entry, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Free()
+	return e.encodeHeader(entry, body.Bytes())
+}
+
+// encodeHeader writes the keys of the entry followed by the given JSON
+// object, the context and the fields encoded without them.
+func (e *ecsEncoder) encodeHeader(entry zapcore.Entry, body []byte) (*buffer.Buffer, error) {
+	enc := e.header.Clone()
+	enc.AddTime("@timestamp", entry.Time)
+	enc.AddString("log.level", formatLevel(&e.config, entry.Level))
+	enc.AddString("message", entry.Message)
+	enc.AddString("ecs.version", ECSVersion)
+	buf, err := enc.EncodeEntry(zapcore.Entry{}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Replace the closing brace of the header with the content of the body.
+	lineEnding := e.config.LineEnding
+	if lineEnding == "" {
+		lineEnding = zapcore.DefaultLineEnding
+	}
+	b := bytes.TrimSuffix(buf.Bytes(), []byte("}"+lineEnding))
+	out := pool.Get()
+	out.Write(b)
+	if len(body) > 0 && !bytes.HasPrefix(body, []byte("{}")) {
+		out.AppendByte(',')
+	}
+	out.Write(bytes.TrimPrefix(body, []byte("{")))
+	buf.Free()
+	return out, nil
+}
+
+// ecsHTTPFields converts the fields of the HTTP logger to ECS fields.
+func ecsHTTPFields(fields []zapcore.Field) []zapcore.Field {
+	result := make([]zapcore.Field, 0, len(fields)+1)
+	for _, f := range fields {
+		switch f.Key {
+		case "method":
+			f.Key = "http.request.method"
+		case "path":
+			path, query, ok := strings.Cut(f.String, "?")
+			f.Key, f.String = "url.path", path
+			if ok {
+				result = append(result, stringField("url.query", query))
+			}
+		case "status":
+			f.Key = "http.response.status_code"
+		case "size":
+			f.Key = "http.response.body.bytes"
+		case "remote-address":
+			f.Key = "client.ip"
+		case "user-agent":
+			f.Key = "user_agent.original"
+		case "referer":
+			if f.String == "" {
+				continue
+			}
+			f.Key = "http.request.referrer"
+		case "protocol":
+			f.Key, f.String = "http.version", strings.TrimPrefix(f.String, "HTTP/")
+		case "duration":
+			continue
+		case "duration-ns":
+			f.Key = "event.duration"
+		}
+		result = append(result, f)
+	}
+	return result
+}
+
+// ecsGRPCFields converts the fields of the gRPC logger to ECS and
+// OpenTelemetry fields.
+func ecsGRPCFields(fields []zapcore.Field) []zapcore.Field {
+	var pkg, service string
+	result := make([]zapcore.Field, 0, len(fields)+2)
+	for _, f := range fields {
+		switch f.Key {
+		case "grpc.package":
+			pkg = f.String
+			continue
+		case "grpc.service":
+			service = f.String
+			continue
+		case "grpc.method":
+			f.Key = "rpc.method"
+		case "grpc.code":
+			if c, ok := ecsGRPCCodes[f.String]; ok {
+				f = zapcore.Field{Key: "rpc.grpc.status_code", Type: zapcore.Int64Type, Integer: c}
+			}
+		case "peer.address":
+			f.Key = "client.address"
+		case "durations":
+			continue
+		case "duration-ns":
+			f.Key = "event.duration"
+		}
+		result = append(result, f)
+	}
+	if pkg != "" {
+		service = pkg + "." + service
+	}
+	return append(result,
+		stringField("rpc.system", "grpc"),
+		stringField("rpc.service", service),
+	)
+}
+
+func stringField(key, value string) zapcore.Field {
+	return zapcore.Field{Key: key, Type: zapcore.StringType, String: value}
+}
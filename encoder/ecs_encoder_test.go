@@ -0,0 +1,134 @@
+package encoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// jsonKeys returns the top-level keys of a JSON object in order.
+func jsonKeys(t *testing.T, b []byte) []string {
+	t.Helper()
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if _, err := dec.Token(); err != nil {
+		t.Fatalf("json.Decoder.Token() error = %v", err)
+	}
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			t.Fatalf("json.Decoder.Token() error = %v", err)
+		}
+		keys = append(keys, tok.(string))
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf("json.Decoder.Decode() error = %v", err)
+		}
+	}
+	return keys
+}
+
+func TestECSEncoder(t *testing.T) {
+	enc := NewECSEncoder(zap.NewProductionEncoderConfig(), ECSConfig{ServiceName: "test"})
+
+	e := encodeJSON(t, enc, zapcore.Entry{
+		Level: zapcore.WarnLevel,
+		Time:  testTime,
+	}, httpFields()...)
+	want := map[string]interface{}{
+		"@timestamp":                "2024-01-02T03:04:05.250Z",
+		"log.level":                 "warn",
+		"ecs.version":               ECSVersion,
+		"service.name":              "test",
+		"trace.id":                  "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span.id":                   "00f067aa0ba902b7",
+		"http.request.id":           "4bf92f3577b34da6a3ce929d0e0e4736",
+		"http.request.method":       "GET",
+		"url.path":                  "/foo",
+		"url.query":                 "bar=baz",
+		"http.response.status_code": float64(404),
+		"http.version":              "1.1",
+		"client.ip":                 "192.0.2.1",
+		"user_agent.original":       "test-agent",
+		"event.duration":            float64(250 * time.Millisecond),
+	}
+	for k, v := range want {
+		if e[k] != v {
+			t.Errorf("entry[%q] = %v, want %v", k, e[k], v)
+		}
+	}
+
+	e = encodeJSON(t, enc, zapcore.Entry{
+		Level: zapcore.InfoLevel,
+		Time:  testTime,
+	},
+		zap.String("system", "grpc"),
+		zap.String("grpc.package", "foo.bar"),
+		zap.String("grpc.service", "Service"),
+		zap.String("grpc.method", "Method"),
+		zap.String("grpc.code", "NotFound"),
+		zap.Duration("durations", time.Second),
+		zap.Int64("duration-ns", time.Second.Nanoseconds()),
+	)
+	want = map[string]interface{}{
+		"log.level":            "info",
+		"service.name":         "test",
+		"rpc.system":           "grpc",
+		"rpc.service":          "foo.bar.Service",
+		"rpc.method":           "Method",
+		"rpc.grpc.status_code": float64(5),
+		"event.duration":       float64(time.Second),
+	}
+	for k, v := range want {
+		if e[k] != v {
+			t.Errorf("entry[%q] = %v, want %v", k, e[k], v)
+		}
+	}
+	for _, k := range []string{"method", "path", "status", "grpc.service", "grpc.code", "trace-id", "durations"} {
+		if _, ok := e[k]; ok {
+			t.Errorf("entry has the field %q", k)
+		}
+	}
+}
+
+func TestECSEncoder_keyOrder(t *testing.T) {
+	enc := NewECSEncoder(zap.NewProductionEncoderConfig(), ECSConfig{})
+	ctx := enc.Clone()
+	ctx.AddString("request-id", "req-1")
+	ctx.AddString("service", "api")
+
+	buf, err := ctx.EncodeEntry(zapcore.Entry{
+		Level:      zapcore.ErrorLevel,
+		Time:       testTime,
+		LoggerName: "api.handler",
+		Message:    "error message",
+		Stack:      "main.main\n\t/src/main.go:42",
+	}, []zapcore.Field{zap.Int("n", 1)})
+	if err != nil {
+		t.Fatalf("EncodeEntry() error = %v", err)
+	}
+	defer buf.Free()
+
+	want := []string{
+		"@timestamp", "log.level", "message", "ecs.version",
+		"http.request.id", "service.name", "n", "log.logger", "error.stack_trace",
+	}
+	if got := jsonKeys(t, buf.Bytes()); !reflect.DeepEqual(got, want) {
+		t.Errorf("keys = %v, want %v", got, want)
+	}
+
+	// Entries without context or fields.
+	buf, err = enc.EncodeEntry(zapcore.Entry{Level: zapcore.InfoLevel, Time: testTime, Message: "message"}, nil)
+	if err != nil {
+		t.Fatalf("EncodeEntry() error = %v", err)
+	}
+	defer buf.Free()
+	if got, want := jsonKeys(t, buf.Bytes()), []string{"@timestamp", "log.level", "message", "ecs.version"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("keys = %v, want %v", got, want)
+	}
+}
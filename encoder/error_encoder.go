@@ -0,0 +1,312 @@
+package encoder
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	pkgerrors "github.com/pkg/errors"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultErrorMaxDepth is the maximum depth of the causes rendered if none is
+// configured.
+const DefaultErrorMaxDepth = 10
+
+// ErrorDetailsSuffix is the suffix added to the key of an error field to get
+// the key of its details.
+const ErrorDetailsSuffix = "Details"
+
+// ErrorCoder returns the code and details of an error, like the ones of a gRPC
+// status, and false if the error does not have a code. It must not unwrap the
+// error, the causes are inspected separately.
+type ErrorCoder func(err error) (code string, details []string, ok bool)
+
+var (
+	errorCodersMu sync.Mutex
+	errorCoders   atomic.Pointer[[]ErrorCoder]
+)
+
+// RegisterErrorCoder adds a function used to get the code and details of the
+// errors rendered by the ErrorMarshaler. The github.com/smallstep/logging/grpclog
+// package registers one for gRPC status errors.
+func RegisterErrorCoder(fn ErrorCoder) {
+	errorCodersMu.Lock()
+	defer errorCodersMu.Unlock()
+	var coders []ErrorCoder
+	if p := errorCoders.Load(); p != nil {
+		coders = append(coders, *p...)
+	}
+	coders = append(coders, fn)
+	errorCoders.Store(&coders)
+}
+
+// ErrorMarshaler is a zapcore.ObjectMarshaler that renders an error with its
+// type, the stack trace of github.com/pkg/errors values, the code and details
+// returned by the registered ErrorCoder functions, and its causes. Causes are
+// found walking the Unwrap chains and the trees created by errors.Join.
+//
+// It is encoded as an object with the keys "message", "type", "code",
+// "details", "stack" and "causes", where causes is an array of objects with
+// the same keys. The text encoder renders it as an indented block after the
+// line.
+type ErrorMarshaler struct {
+	root *errorNode
+}
+
+// NewErrorMarshaler returns the ErrorMarshaler for the given error. Causes
+// deeper than maxDepth are not rendered; if maxDepth is zero,
+// DefaultErrorMaxDepth is used.
+func NewErrorMarshaler(err error, maxDepth int) *ErrorMarshaler {
+	if maxDepth <= 0 {
+		maxDepth = DefaultErrorMaxDepth
+	}
+	return &ErrorMarshaler{
+		root: newErrorNode(err, maxDepth),
+	}
+}
+
+// ErrorFields replaces the fields created with zap.Error or zap.NamedError
+// with a string field with the message of the error. If the error has causes,
+// a stack trace or a code, a field with the key followed by
+// ErrorDetailsSuffix, like "errorDetails", is added after it using an
+// ErrorMarshaler. The original slice is returned if there are no error
+// fields.
+func ErrorFields(fields []zapcore.Field, maxDepth int) []zapcore.Field {
+	var result []zapcore.Field
+	for i, f := range fields {
+		err, ok := f.Interface.(error)
+		if f.Type != zapcore.ErrorType || !ok {
+			if result != nil {
+				result = append(result, f)
+			}
+			continue
+		}
+		if result == nil {
+			result = make([]zapcore.Field, i, len(fields)+1)
+			copy(result, fields[:i])
+		}
+		m := NewErrorMarshaler(err, maxDepth)
+		result = append(result, zapcore.Field{
+			Key:    f.Key,
+			Type:   zapcore.StringType,
+			String: m.Error(),
+		})
+		if m.root.hasDetails() {
+			result = append(result, zapcore.Field{
+				Key:       f.Key + ErrorDetailsSuffix,
+				Type:      zapcore.ObjectMarshalerType,
+				Interface: m,
+			})
+		}
+	}
+	if result == nil {
+		return fields
+	}
+	return result
+}
+
+// Error returns the message of the error.
+func (m *ErrorMarshaler) Error() string {
+	return m.root.message
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler.
+func (m *ErrorMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return m.root.MarshalLogObject(enc)
+}
+
+// errorNode is an error of the tree of causes.
+type errorNode struct {
+	message   string
+	typ       string
+	code      string
+	details   []string
+	stack     []string
+	causes    []*errorNode
+	truncated bool
+}
+
+func newErrorNode(err error, depth int) *errorNode {
+	n := &errorNode{
+		message: errorMessage(err),
+	}
+
+	// Collapse the wrappers that only add a stack trace or a status, like the
+	// ones created by errors.WithStack, into the error that they wrap.
+	n.addInfo(err)
+	for {
+		cause := errors.Unwrap(err)
+		if cause == nil || errorMessage(cause) != n.message {
+			break
+		}
+		err = cause
+		n.addInfo(err)
+	}
+	n.typ = fmt.Sprintf("%T", err)
+
+	var causes []error
+	switch e := err.(type) {
+	case interface{ Unwrap() []error }:
+		causes = e.Unwrap()
+	case interface{ Unwrap() error }:
+		if cause := e.Unwrap(); cause != nil {
+			causes = []error{cause}
+		}
+	}
+	if len(causes) == 0 {
+		return n
+	}
+	if depth <= 0 {
+		n.truncated = true
+		return n
+	}
+	for _, cause := range causes {
+		if cause != nil {
+			n.causes = append(n.causes, newErrorNode(cause, depth-1))
+		}
+	}
+
+	// The stack trace of a wrapped error is deeper than the ones added by the
+	// wrappers, so only the innermost one is kept.
+	if len(n.causes) == 1 && n.causes[0].hasStack() {
+		n.stack = nil
+	}
+	return n
+}
+
+// addInfo adds the stack trace and the code of the given error if they are
+// not already set.
+func (n *errorNode) addInfo(err error) {
+	if st, ok := err.(interface{ StackTrace() pkgerrors.StackTrace }); ok && n.stack == nil {
+		n.stack = formatStack(st.StackTrace())
+	}
+	if n.code != "" {
+		return
+	}
+	if p := errorCoders.Load(); p != nil {
+		for _, fn := range *p {
+			if code, details, ok := fn(err); ok {
+				n.code, n.details = code, details
+				return
+			}
+		}
+	}
+}
+
+// hasDetails returns true if the node has more information than its message
+// and type.
+func (n *errorNode) hasDetails() bool {
+	return n.code != "" || len(n.details) > 0 || len(n.stack) > 0 || len(n.causes) > 0 || n.truncated
+}
+
+// hasStack returns true if the node or its single chain of causes has a stack
+// trace.
+func (n *errorNode) hasStack() bool {
+	for ; n != nil; n = n.cause() {
+		if n.stack != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *errorNode) cause() *errorNode {
+	if len(n.causes) == 1 {
+		return n.causes[0]
+	}
+	return nil
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler.
+func (n *errorNode) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("message", n.message)
+	enc.AddString("type", n.typ)
+	if n.code != "" {
+		enc.AddString("code", n.code)
+	}
+	if len(n.details) > 0 {
+		_ = enc.AddArray("details", stringArray(n.details))
+	}
+	if len(n.stack) > 0 {
+		_ = enc.AddArray("stack", stringArray(n.stack))
+	}
+	if len(n.causes) > 0 {
+		_ = enc.AddArray("causes", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+			for _, c := range n.causes {
+				_ = arr.AppendObject(c)
+			}
+			return nil
+		}))
+	}
+	if n.truncated {
+		enc.AddBool("truncated", true)
+	}
+	return nil
+}
+
+// appendText appends the node as an indented block.
+func (n *errorNode) appendText(buf *buffer.Buffer, indent string) {
+	buf.AppendString(indent)
+	buf.AppendString(n.typ)
+	buf.AppendString(": ")
+	buf.AppendString(strings.ReplaceAll(n.message, "\n", "\n"+indent+"  "))
+	buf.AppendByte('\n')
+	if n.code != "" {
+		buf.AppendString(indent + "  code: " + n.code + "\n")
+	}
+	for _, d := range n.details {
+		buf.AppendString(indent + "  detail: " + d + "\n")
+	}
+	for _, frame := range n.stack {
+		buf.AppendString(indent + "  at " + frame + "\n")
+	}
+	for _, c := range n.causes {
+		buf.AppendString(indent + "  caused by:\n")
+		c.appendText(buf, indent+"    ")
+	}
+	if n.truncated {
+		buf.AppendString(indent + "  ...\n")
+	}
+}
+
+type stringArray []string
+
+func (a stringArray) MarshalLogArray(arr zapcore.ArrayEncoder) error {
+	for _, s := range a {
+		arr.AppendString(s)
+	}
+	return nil
+}
+
+// errorMessage returns the message of the error, recovering from the panics
+// of nil receivers.
+func errorMessage(err error) (msg string) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg = fmt.Sprintf("<PANIC=%v>", r)
+		}
+	}()
+	return err.Error()
+}
+
+// formatStack returns the frames of the stack trace using the format
+// "function file:line".
+func formatStack(st pkgerrors.StackTrace) []string {
+	frames := make([]string, 0, len(st))
+	for _, f := range st {
+		pc := uintptr(f) - 1
+		fn := runtime.FuncForPC(pc)
+		if fn == nil {
+			frames = append(frames, "unknown")
+			continue
+		}
+		file, line := fn.FileLine(pc)
+		frames = append(frames, fmt.Sprintf("%s %s:%d", fn.Name(), file, line))
+	}
+	return frames
+}
@@ -0,0 +1,188 @@
+package encoder
+
+import (
+	"strconv"
+	"time"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/smallstep/logging/tracing"
+)
+
+// Keys of the special fields of Google Cloud Logging.
+const (
+	gcpTraceKey          = "logging.googleapis.com/trace"
+	gcpSpanIDKey         = "logging.googleapis.com/spanId"
+	gcpTraceSampledKey   = "logging.googleapis.com/trace_sampled"
+	gcpSourceLocationKey = "logging.googleapis.com/sourceLocation"
+	gcpHTTPRequestKey    = "httpRequest"
+)
+
+// gcpHTTPRequestFields are the fields of the HTTP logger that are moved to the
+// httpRequest object and the keys used in it.
+var gcpHTTPRequestFields = map[string]string{
+	"method":         "requestMethod",
+	"path":           "requestUrl",
+	"status":         "status",
+	"size":           "responseSize",
+	"user-agent":     "userAgent",
+	"remote-address": "remoteIp",
+	"referer":        "referer",
+	"protocol":       "protocol",
+	"duration":       "latency",
+}
+
+// GCPConfig contains the values used in the Google Cloud Logging entries.
+type GCPConfig struct {
+	// ProjectID is the Google Cloud project used in the resource name of the
+	// traces. If it's empty, only the trace id is used.
+	ProjectID string
+}
+
+// NewGCPEncoder returns a new JSON encoder that logs messages with the
+// structured format of Google Cloud Logging. Each entry sets "timestamp",
+// "severity" using the Cloud Logging severity of the level, "message", and
+// "logging.googleapis.com/sourceLocation" if the caller is enabled.
+//
+// The "tracing-id" fields are converted to the
+// "logging.googleapis.com/trace", "logging.googleapis.com/spanId" and
+// "logging.googleapis.com/trace_sampled" fields, and the fields of the
+// entries written by the HTTP logger, the ones with "system" set to "http",
+// are grouped in the "httpRequest" object.
+func NewGCPEncoder(config zapcore.EncoderConfig, gc GCPConfig) zapcore.Encoder {
+	config.TimeKey = "timestamp"
+	config.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+	config.LevelKey = "severity"
+	config.EncodeLevel = encodeGCPSeverity
+	config.MessageKey = "message"
+	config.CallerKey = zapcore.OmitKey
+	config.FunctionKey = zapcore.OmitKey
+	return &gcpEncoder{
+		Encoder:   zapcore.NewJSONEncoder(config),
+		projectID: gc.ProjectID,
+	}
+}
+
+type gcpEncoder struct {
+	zapcore.Encoder
+	projectID string
+}
+
+// Clone copies the encoder, ensuring that adding fields to the copy doesn't
+// affect the original.
+func (e *gcpEncoder) Clone() zapcore.Encoder {
+	return &gcpEncoder{
+		Encoder:   e.Encoder.Clone(),
+		projectID: e.projectID,
+	}
+}
+
+// AddString adds a string field, converting the tracing-id to the trace
+// fields of Cloud Logging.
+func (e *gcpEncoder) AddString(key, value string) {
+	e.Encoder.AddString(key, value)
+	if key != "tracing-id" {
+		return
+	}
+	tp, err := tracing.Parse(value)
+	if err != nil {
+		return
+	}
+	trace := tp.TraceID()
+	if e.projectID != "" {
+		trace = "projects/" + e.projectID + "/traces/" + trace
+	}
+	e.Encoder.AddString(gcpTraceKey, trace)
+	e.Encoder.AddString(gcpSpanIDKey, tp.SpanID())
+	e.Encoder.AddBool(gcpTraceSampledKey, tp.Sampled())
+}
+
+// EncodeEntry encodes an entry and fields, along with any accumulated context,
+// into a byte buffer and returns it.
+func (e *gcpEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final := e.Clone().(*gcpEncoder)
+	if entrySystem(fields) == "http" {
+		req := make(gcpHTTPRequest, 0, len(gcpHTTPRequestFields))
+		for _, f := range fields {
+			if _, ok := gcpHTTPRequestFields[f.Key]; ok {
+				req = append(req, f)
+			} else {
+				f.AddTo(final)
+			}
+		}
+		_ = final.AddObject(gcpHTTPRequestKey, req)
+	} else {
+		for i := range fields {
+			fields[i].AddTo(final)
+		}
+	}
+	if entry.Caller.Defined {
+		_ = final.AddObject(gcpSourceLocationKey, gcpSourceLocation(entry.Caller))
+	}
+	return final.Encoder.EncodeEntry(entry, nil)
+}
+
+// GCPSeverity returns the Google Cloud Logging severity for the given level.
+// The levels below debug, like trace, use DEFAULT, the only severity lower
+// than DEBUG.
+func GCPSeverity(level zapcore.Level) string {
+	switch level {
+	case zapcore.DebugLevel:
+		return "DEBUG"
+	case zapcore.InfoLevel:
+		return "INFO"
+	case zapcore.WarnLevel:
+		return "WARNING"
+	case zapcore.ErrorLevel:
+		return "ERROR"
+	case zapcore.DPanicLevel, zapcore.PanicLevel:
+		return "CRITICAL"
+	case zapcore.FatalLevel:
+		return "ALERT"
+	default:
+		if level < zapcore.DebugLevel {
+			return "DEFAULT"
+		}
+		return "EMERGENCY"
+	}
+}
+
+func encodeGCPSeverity(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	enc.AppendString(GCPSeverity(level))
+}
+
+// gcpHTTPRequest is the HttpRequest object of Cloud Logging, created from the
+// fields of the HTTP logger.
+type gcpHTTPRequest []zapcore.Field
+
+func (r gcpHTTPRequest) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, f := range r {
+		key := gcpHTTPRequestFields[f.Key]
+		switch {
+		case f.Type == zapcore.DurationType:
+			// The latency uses the JSON format of google.protobuf.Duration.
+			enc.AddString(key, strconv.FormatFloat(time.Duration(f.Integer).Seconds(), 'f', -1, 64)+"s")
+		case key == "responseSize":
+			// int64 values use strings in the JSON format of protobuf.
+			enc.AddString(key, strconv.FormatInt(f.Integer, 10))
+		case f.Type == zapcore.StringType && f.String == "":
+		default:
+			f.Key = key
+			f.AddTo(enc)
+		}
+	}
+	return nil
+}
+
+// gcpSourceLocation is the LogEntrySourceLocation object of Cloud Logging.
+type gcpSourceLocation zapcore.EntryCaller
+
+func (c gcpSourceLocation) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("file", c.File)
+	enc.AddString("line", strconv.Itoa(c.Line))
+	if c.Function != "" {
+		enc.AddString("function", c.Function)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package encoder
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+var testTime = time.Date(2024, 1, 2, 3, 4, 5, 250000000, time.UTC)
+
+// encodeJSON encodes the entry and fields and decodes the result.
+func encodeJSON(t *testing.T, enc zapcore.Encoder, ent zapcore.Entry, fields ...zapcore.Field) map[string]interface{} {
+	t.Helper()
+	buf, err := enc.EncodeEntry(ent, fields)
+	if err != nil {
+		t.Fatalf("EncodeEntry() error = %v", err)
+	}
+	defer buf.Free()
+	var m map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	return m
+}
+
+// httpFields returns the fields of an entry written by the httplog
+// middleware.
+func httpFields() []zapcore.Field {
+	return []zapcore.Field{
+		zap.String("name", "test"),
+		zap.String("system", "http"),
+		zap.String("request-id", "4bf92f3577b34da6a3ce929d0e0e4736"),
+		zap.String("tracing-id", testTraceparent),
+		zap.String("remote-address", "192.0.2.1"),
+		zap.Duration("duration", 250*time.Millisecond),
+		zap.Int64("duration-ns", (250 * time.Millisecond).Nanoseconds()),
+		zap.String("method", "GET"),
+		zap.String("path", "/foo?bar=baz"),
+		zap.String("protocol", "HTTP/1.1"),
+		zap.Int("status", 404),
+		zap.Int("size", 0),
+		zap.String("referer", ""),
+		zap.String("user-agent", "test-agent"),
+	}
+}
+
+func TestGCPEncoder(t *testing.T) {
+	enc := NewGCPEncoder(zap.NewProductionEncoderConfig(), GCPConfig{ProjectID: "my-project"})
+
+	e := encodeJSON(t, enc, zapcore.Entry{
+		Level:   zapcore.WarnLevel,
+		Time:    testTime,
+		Message: "",
+	}, httpFields()...)
+	want := map[string]interface{}{
+		"severity":                             "WARNING",
+		"timestamp":                            "2024-01-02T03:04:05.25Z",
+		"logging.googleapis.com/trace":         "projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736",
+		"logging.googleapis.com/spanId":        "00f067aa0ba902b7",
+		"logging.googleapis.com/trace_sampled": true,
+	}
+	for k, v := range want {
+		if e[k] != v {
+			t.Errorf("entry[%q] = %v, want %v", k, e[k], v)
+		}
+	}
+	wantRequest := map[string]interface{}{
+		"requestMethod": "GET",
+		"requestUrl":    "/foo?bar=baz",
+		"status":        float64(404),
+		"responseSize":  "0",
+		"userAgent":     "test-agent",
+		"remoteIp":      "192.0.2.1",
+		"protocol":      "HTTP/1.1",
+		"latency":       "0.25s",
+	}
+	if !reflect.DeepEqual(e["httpRequest"], wantRequest) {
+		t.Errorf("entry[\"httpRequest\"] = %v, want %v", e["httpRequest"], wantRequest)
+	}
+	if _, ok := e["method"]; ok {
+		t.Errorf("entry has the HTTP fields: %v", e)
+	}
+
+	e = encodeJSON(t, enc, zapcore.Entry{
+		Level:   zapcore.ErrorLevel,
+		Time:    testTime,
+		Message: "error message",
+		Caller:  zapcore.EntryCaller{Defined: true, File: "/src/main.go", Line: 42, Function: "main.main"},
+	})
+	if e["severity"] != "ERROR" || e["message"] != "error message" {
+		t.Errorf("unexpected entry %v", e)
+	}
+	wantLocation := map[string]interface{}{"file": "/src/main.go", "line": "42", "function": "main.main"}
+	if !reflect.DeepEqual(e["logging.googleapis.com/sourceLocation"], wantLocation) {
+		t.Errorf("entry[\"logging.googleapis.com/sourceLocation\"] = %v, want %v", e["logging.googleapis.com/sourceLocation"], wantLocation)
+	}
+}
+
+func TestGCPSeverity(t *testing.T) {
+	tests := []struct {
+		level zapcore.Level
+		want  string
+	}{
+		{zapcore.Level(-3), "DEFAULT"},
+		{zapcore.Level(-2), "DEFAULT"},
+		{zapcore.DebugLevel, "DEBUG"},
+		{zapcore.InfoLevel, "INFO"},
+		{zapcore.WarnLevel, "WARNING"},
+		{zapcore.ErrorLevel, "ERROR"},
+		{zapcore.DPanicLevel, "CRITICAL"},
+		{zapcore.PanicLevel, "CRITICAL"},
+		{zapcore.FatalLevel, "ALERT"},
+		{zapcore.Level(6), "EMERGENCY"},
+	}
+	for _, tt := range tests {
+		if got := GCPSeverity(tt.level); got != tt.want {
+			t.Errorf("GCPSeverity(%d) = %q, want %q", tt.level, got, tt.want)
+		}
+	}
+}
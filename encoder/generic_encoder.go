@@ -2,6 +2,7 @@ package encoder
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 
 	"go.uber.org/zap/buffer"
@@ -113,3 +114,64 @@ func (e *genericEncoder) AppendReflected(value interface{}) error {
 	e.buf.AppendString(string(b))
 	return nil
 }
+
+// formatCaller returns the caller encoded with the EncodeCaller of the given
+// configuration, or its trimmed path if it's not set.
+func formatCaller(config *zapcore.EncoderConfig, caller zapcore.EntryCaller) string {
+	if config.EncodeCaller == nil {
+		return caller.TrimmedPath()
+	}
+	enc := &genericEncoder{EncoderConfig: config, buf: pool.Get()}
+	defer enc.buf.Free()
+	config.EncodeCaller(caller, enc)
+	return enc.buf.String()
+}
+
+// formatLevel returns the level encoded with the EncodeLevel of the given
+// configuration, or its name if it's not set.
+func formatLevel(config *zapcore.EncoderConfig, level zapcore.Level) string {
+	if config.EncodeLevel == nil {
+		return level.String()
+	}
+	enc := &genericEncoder{EncoderConfig: config, buf: pool.Get()}
+	defer enc.buf.Free()
+	config.EncodeLevel(level, enc)
+	return enc.buf.String()
+}
+
+// formatTime returns the time encoded with the EncodeTime of the given
+// configuration.
+func formatTime(config *zapcore.EncoderConfig, t time.Time) string {
+	enc := &genericEncoder{EncoderConfig: config, buf: pool.Get(), formatTime: time.RFC3339}
+	defer enc.buf.Free()
+	enc.AppendTime(t)
+	return enc.buf.String()
+}
+
+// formatDuration returns the duration encoded with the EncodeDuration of the
+// given configuration.
+func formatDuration(config *zapcore.EncoderConfig, d time.Duration) string {
+	enc := &genericEncoder{EncoderConfig: config, buf: pool.Get()}
+	defer enc.buf.Free()
+	enc.AppendDuration(d)
+	return enc.buf.String()
+}
+
+// entrySystem returns the value of the "system" field, "http" or "grpc" in
+// the entries of the HTTP and gRPC loggers.
+func entrySystem(fields []zapcore.Field) string {
+	for _, f := range fields {
+		if f.Key == "system" && f.Type == zapcore.StringType {
+			return f.String
+		}
+	}
+	return ""
+}
+
+// appendBlock appends the given multi-line text indented after the key.
+func appendBlock(buf *buffer.Buffer, key, text string) {
+	buf.AppendString("  " + key + ":\n")
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		buf.AppendString("    " + line + "\n")
+	}
+}
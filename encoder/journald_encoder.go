@@ -0,0 +1,150 @@
+package encoder
+
+import (
+	"encoding/binary"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// journaldMaxFieldName is the maximum length of a journal field name.
+const journaldMaxFieldName = 64
+
+// journaldReserved are the fields set by the encoder, entry fields with the
+// same name get the "FIELD_" prefix.
+var journaldReserved = map[string]bool{
+	"MESSAGE":           true,
+	"PRIORITY":          true,
+	"SYSLOG_IDENTIFIER": true,
+	"CODE_FILE":         true,
+	"CODE_LINE":         true,
+	"CODE_FUNC":         true,
+}
+
+// JournaldConfig contains the values used in the journal entries.
+type JournaldConfig struct {
+	// Identifier is the SYSLOG_IDENTIFIER used if the entry does not have a
+	// logger name.
+	Identifier string
+}
+
+// NewJournaldEncoder returns a new encoder that logs messages with the native
+// protocol of systemd-journald. Each entry sets MESSAGE, PRIORITY using the
+// syslog severity of the level, and SYSLOG_IDENTIFIER using the logger name.
+// Fields are added with their names in uppercase, replacing the characters
+// not allowed with underscores.
+func NewJournaldEncoder(config zapcore.EncoderConfig, jc JournaldConfig) zapcore.Encoder {
+	return &journaldEncoder{
+		EncoderConfig:    &config,
+		MapObjectEncoder: zapcore.NewMapObjectEncoder(),
+		identifier:       jc.Identifier,
+	}
+}
+
+type journaldEncoder struct {
+	*zapcore.EncoderConfig
+	*zapcore.MapObjectEncoder
+	identifier string
+}
+
+// Clone copies the encoder, ensuring that adding fields to the copy doesn't
+// affect the original.
+func (e *journaldEncoder) Clone() zapcore.Encoder {
+	enc := &journaldEncoder{
+		EncoderConfig:    e.EncoderConfig,
+		MapObjectEncoder: zapcore.NewMapObjectEncoder(),
+		identifier:       e.identifier,
+	}
+	for k, v := range e.Fields {
+		enc.Fields[k] = v
+	}
+	return enc
+}
+
+// EncodeEntry encodes an entry and fields, along with any accumulated context,
+// into a byte buffer and returns it.
+func (e *journaldEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final := e.Clone().(*journaldEncoder)
+	for i := range fields {
+		fields[i].AddTo(final)
+	}
+
+	identifier := entry.LoggerName
+	if identifier == "" {
+		identifier = e.identifier
+	}
+
+	buf := pool.Get()
+	appendJournaldField(buf, "MESSAGE", entry.Message)
+	appendJournaldField(buf, "PRIORITY", strconv.Itoa(SyslogSeverity(entry.Level)))
+	if identifier != "" {
+		appendJournaldField(buf, "SYSLOG_IDENTIFIER", identifier)
+	}
+	if entry.Caller.Defined {
+		appendJournaldField(buf, "CODE_FILE", entry.Caller.File)
+		appendJournaldField(buf, "CODE_LINE", strconv.Itoa(entry.Caller.Line))
+		if entry.Caller.Function != "" {
+			appendJournaldField(buf, "CODE_FUNC", entry.Caller.Function)
+		}
+	}
+	if entry.Stack != "" && e.StacktraceKey != "" {
+		final.Fields[e.StacktraceKey] = entry.Stack
+	}
+
+	keys := make([]string, 0, len(final.Fields))
+	for k := range final.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		appendJournaldField(buf, JournaldFieldName(k), formatValue(final.Fields[k]))
+	}
+	return buf, nil
+}
+
+// JournaldFieldName converts the given key to a valid journal field name. The
+// name is converted to uppercase, characters other than letters, digits and
+// underscores are replaced by underscores, leading underscores are removed,
+// and names starting with a digit or used by the encoder get the "FIELD_"
+// prefix.
+func JournaldFieldName(key string) string {
+	name := strings.TrimLeft(strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		default:
+			return '_'
+		}
+	}, key), "_")
+
+	if name == "" || (name[0] >= '0' && name[0] <= '9') || journaldReserved[name] {
+		name = "FIELD_" + name
+	}
+	if len(name) > journaldMaxFieldName {
+		name = name[:journaldMaxFieldName]
+	}
+	return name
+}
+
+// appendJournaldField appends a field using the "NAME=value\n" format, or the
+// binary format if the value contains new lines.
+func appendJournaldField(buf *buffer.Buffer, name, value string) {
+	buf.AppendString(name)
+	if !strings.Contains(value, "\n") {
+		buf.AppendByte('=')
+		buf.AppendString(value)
+		buf.AppendByte('\n')
+		return
+	}
+	buf.AppendByte('\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	_, _ = buf.Write(size[:])
+	buf.AppendString(value)
+	buf.AppendByte('\n')
+}
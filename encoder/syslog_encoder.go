@@ -0,0 +1,272 @@
+package encoder
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// Syslog severities as defined in RFC 5424.
+const (
+	SeverityEmergency = iota
+	SeverityAlert
+	SeverityCritical
+	SeverityError
+	SeverityWarning
+	SeverityNotice
+	SeverityInformational
+	SeverityDebug
+)
+
+// DefaultSDID is the SD-ID used for the structured data if none is
+// configured. It uses the enterprise number reserved for documentation.
+const DefaultSDID = "fields@32473"
+
+// syslogTimeFormat is the RFC 3339 format with microseconds used in the
+// TIMESTAMP.
+const syslogTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// SyslogConfig contains the values used in the header of the syslog messages.
+type SyslogConfig struct {
+	// Facility is the syslog facility code.
+	Facility int
+	// Hostname is the HOSTNAME field.
+	Hostname string
+	// AppName is the APP-NAME field.
+	AppName string
+	// ProcID is the PROCID field.
+	ProcID string
+	// SDID is the SD-ID of the element with the fields of the entry. Defaults
+	// to DefaultSDID.
+	SDID string
+}
+
+// NewSyslogEncoder returns a new encoder that logs messages with the RFC 5424
+// syslog format. The name of the logger is used as the MSGID and the fields
+// are added as parameters of a single SD-ELEMENT. Each message will follow
+// the format:
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD-ID key="value"...] MSG
+func NewSyslogEncoder(config zapcore.EncoderConfig, sc SyslogConfig) zapcore.Encoder {
+	if sc.SDID == "" {
+		sc.SDID = DefaultSDID
+	}
+	return &syslogEncoder{
+		EncoderConfig:    &config,
+		MapObjectEncoder: zapcore.NewMapObjectEncoder(),
+		facility:         sc.Facility,
+		hostname:         syslogHeaderField(sc.Hostname, 255),
+		appName:          syslogHeaderField(sc.AppName, 48),
+		procID:           syslogHeaderField(sc.ProcID, 128),
+		sdID:             syslogName(sc.SDID),
+	}
+}
+
+type syslogEncoder struct {
+	*zapcore.EncoderConfig
+	*zapcore.MapObjectEncoder
+	facility int
+	hostname string
+	appName  string
+	procID   string
+	sdID     string
+}
+
+// Clone copies the encoder, ensuring that adding fields to the copy doesn't
+// affect the original.
+func (e *syslogEncoder) Clone() zapcore.Encoder {
+	enc := e.clone()
+	for k, v := range e.Fields {
+		enc.Fields[k] = v
+	}
+	return enc
+}
+
+func (e *syslogEncoder) clone() *syslogEncoder {
+	return &syslogEncoder{
+		EncoderConfig:    e.EncoderConfig,
+		MapObjectEncoder: zapcore.NewMapObjectEncoder(),
+		facility:         e.facility,
+		hostname:         e.hostname,
+		appName:          e.appName,
+		procID:           e.procID,
+		sdID:             e.sdID,
+	}
+}
+
+// EncodeEntry encodes an entry and fields, along with any accumulated context,
+// into a byte buffer and returns it. The message does not include a trailing
+// new line, the framing is done by the transport.
+func (e *syslogEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final := e.Clone().(*syslogEncoder)
+	for i := range fields {
+		fields[i].AddTo(final)
+	}
+
+	buf := pool.Get()
+	buf.AppendByte('<')
+	buf.AppendInt(int64(e.facility*8 + SyslogSeverity(entry.Level)))
+	buf.AppendString(">1 ")
+	buf.AppendString(entry.Time.Format(syslogTimeFormat))
+	buf.AppendByte(' ')
+	buf.AppendString(e.hostname)
+	buf.AppendByte(' ')
+	buf.AppendString(e.appName)
+	buf.AppendByte(' ')
+	buf.AppendString(e.procID)
+	buf.AppendByte(' ')
+	buf.AppendString(syslogHeaderField(entry.LoggerName, 32))
+	buf.AppendByte(' ')
+	final.appendStructuredData(buf, entry)
+	if entry.Message != "" {
+		buf.AppendByte(' ')
+		buf.AppendString(entry.Message)
+	}
+	return buf, nil
+}
+
+// appendStructuredData appends the SD-ELEMENT with the fields sorted by key,
+// or the nil value if there are no fields.
+func (e *syslogEncoder) appendStructuredData(buf *buffer.Buffer, entry zapcore.Entry) {
+	if entry.Caller.Defined {
+		if e.CallerKey != "" {
+			e.Fields[e.CallerKey] = formatCaller(e.EncoderConfig, entry.Caller)
+		}
+		if e.FunctionKey != "" && entry.Caller.Function != "" {
+			e.Fields[e.FunctionKey] = entry.Caller.Function
+		}
+	}
+	if entry.Stack != "" && e.StacktraceKey != "" {
+		e.Fields[e.StacktraceKey] = entry.Stack
+	}
+	if len(e.Fields) == 0 {
+		buf.AppendByte('-')
+		return
+	}
+
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	buf.AppendByte('[')
+	buf.AppendString(e.sdID)
+	for _, k := range keys {
+		name := syslogName(k)
+		if name == "" {
+			continue
+		}
+		buf.AppendByte(' ')
+		buf.AppendString(name)
+		buf.AppendString(`="`)
+		appendParamValue(buf, formatValue(e.Fields[k]))
+		buf.AppendByte('"')
+	}
+	buf.AppendByte(']')
+}
+
+// SyslogSeverity returns the syslog severity for the given level.
+func SyslogSeverity(level zapcore.Level) int {
+	switch level {
+	case zapcore.DebugLevel:
+		return SeverityDebug
+	case zapcore.InfoLevel:
+		return SeverityInformational
+	case zapcore.WarnLevel:
+		return SeverityWarning
+	case zapcore.ErrorLevel:
+		return SeverityError
+	case zapcore.DPanicLevel, zapcore.PanicLevel:
+		return SeverityCritical
+	case zapcore.FatalLevel:
+		return SeverityAlert
+	default:
+		if level < zapcore.DebugLevel {
+			return SeverityDebug
+		}
+		return SeverityEmergency
+	}
+}
+
+// syslogHeaderField returns the given value as a header field, using the nil
+// value if it's empty, removing the characters that are not printable ASCII
+// and truncating it to the maximum length.
+func syslogHeaderField(s string, maxLen int) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+		return r
+	}, s)
+	if len(s) > maxLen {
+		s = s[:maxLen]
+	}
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// syslogName returns the given value as an SD-NAME, removing the characters
+// not allowed and truncating it to 32 characters.
+func syslogName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 || r == '=' || r == ']' || r == '"' || r == ' ' {
+			return -1
+		}
+		return r
+	}, s)
+	if len(s) > 32 {
+		s = s[:32]
+	}
+	return s
+}
+
+// appendParamValue appends a PARAM-VALUE escaping '"', '\' and ']'.
+func appendParamValue(buf *buffer.Buffer, s string) {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\\', ']':
+			buf.AppendByte('\\')
+			buf.AppendByte(c)
+		default:
+			buf.AppendByte(c)
+		}
+	}
+}
+
+// formatValue returns the string representation of a value added to a
+// MapObjectEncoder.
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Duration:
+		return v.String()
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return v.String()
+	case map[string]interface{}, []interface{}:
+		if b, err := json.Marshal(v); err == nil {
+			return string(b)
+		}
+		return fmt.Sprint(v)
+	default:
+		if b, err := json.Marshal(v); err == nil {
+			return strings.Trim(string(b), `"`)
+		}
+		return fmt.Sprint(v)
+	}
+}
@@ -21,7 +21,12 @@ const (
 )
 
 // NewTextEncoder returns a new text encoder that logs messages similar to
-// logrus text encoder.
+// logrus text encoder. The level is written using the EncodeLevel of the
+// configuration, or in upper case if it's not set.
+//
+// Like the console encoder of zap, the keys of the time, logger name and
+// message are not written, but the elements with an empty key are omitted.
+// If set, the time is written before the level, and the logger name after it.
 func NewTextEncoder(config zapcore.EncoderConfig) zapcore.Encoder {
 	return &textEncoder{
 		genericEncoder: newGenericEncoder(config),
@@ -31,6 +36,16 @@ func NewTextEncoder(config zapcore.EncoderConfig) zapcore.Encoder {
 type textEncoder struct {
 	*genericEncoder
 	colorStart string
+	errors     []textError
+	// keys contains the positions of the keys of the context fields, they are
+	// colored when the entries are encoded.
+	keys [][2]int
+}
+
+// textError is an error rendered as an indented block after the line.
+type textError struct {
+	key  string
+	node *errorNode
 }
 
 // Clone copies the encoder, ensuring that adding fields to the copy doesn't
@@ -38,6 +53,8 @@ type textEncoder struct {
 func (e *textEncoder) Clone() zapcore.Encoder {
 	enc := e.clone()
 	enc.buf.Write(e.buf.Bytes())
+	enc.errors = e.errors[:len(e.errors):len(e.errors)]
+	enc.keys = e.keys[:len(e.keys):len(e.keys)]
 	return enc
 }
 
@@ -49,21 +66,54 @@ func (e *textEncoder) clone() *textEncoder {
 }
 
 func (e *textEncoder) addKey(key string) {
+	// Separate the context fields added with With.
+	if b := e.buf.Bytes(); len(b) > 0 && b[len(b)-1] != ' ' {
+		e.AppendString(" ")
+	}
 	if e.colorStart != "" {
 		e.AppendString(e.colorStart + key + colorEnd)
 	} else {
+		// The color of the context fields depends on the level of the
+		// entries.
+		start := e.buf.Len()
 		e.AppendString(key)
+		e.keys = append(e.keys, [2]int{start, e.buf.Len()})
 	}
 	e.AppendString("=")
 }
 
-func (e *textEncoder) addMessage(level, message string) {
+// appendContext appends the context fields of the given encoder, coloring the
+// keys like the fields of the entry.
+func (e *textEncoder) appendContext(ctx *textEncoder) {
+	b := ctx.buf.Bytes()
+	var last int
+	for _, k := range ctx.keys {
+		e.buf.Write(b[last:k[0]])
+		e.AppendString(e.colorStart)
+		e.buf.Write(b[k[0]:k[1]])
+		e.AppendString(colorEnd)
+		last = k[1]
+	}
+	e.buf.Write(b[last:])
+}
+
+func (e *textEncoder) addMessage(entry zapcore.Entry, level string) {
+	if e.TimeKey != "" {
+		e.AppendString(formatTime(e.EncoderConfig, entry.Time) + " ")
+	}
 	if e.colorStart != "" {
 		e.AppendString(e.colorStart + level + colorEnd)
 	} else {
 		e.AppendString(level)
 	}
-	e.AppendString(fmt.Sprintf(" %-44s ", message))
+	if e.NameKey != "" && entry.LoggerName != "" {
+		e.AppendString(" " + entry.LoggerName)
+	}
+	if e.MessageKey != "" {
+		e.AppendString(fmt.Sprintf(" %-44s ", entry.Message))
+	} else {
+		e.AppendString(" ")
+	}
 }
 
 // EncodeEntry encodes an entry and fields, along with any accumulated context,
@@ -72,22 +122,50 @@ func (e *textEncoder) addMessage(level, message string) {
 func (e *textEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
 	final := e.clone()
 
-	switch entry.Level {
-	case zapcore.WarnLevel:
+	switch {
+	case entry.Level < zapcore.DebugLevel:
+		final.colorStart = gray
+	case entry.Level == zapcore.WarnLevel:
 		final.colorStart = yellow
-	case zapcore.ErrorLevel, zapcore.DPanicLevel, zapcore.PanicLevel, zapcore.FatalLevel:
+	case entry.Level >= zapcore.ErrorLevel:
 		final.colorStart = red
 	default:
 		final.colorStart = blue
 	}
 
-	final.addMessage(strings.ToUpper(entry.Level.String()), entry.Message)
+	if final.EncodeLevel == nil {
+		final.addMessage(entry, strings.ToUpper(entry.Level.String()))
+	} else {
+		final.addMessage(entry, formatLevel(final.EncoderConfig, entry.Level))
+	}
+	if e.buf.Len() > 0 {
+		final.appendContext(e)
+		final.buf.AppendString(" ")
+		final.errors = e.errors[:len(e.errors):len(e.errors)]
+	}
+	if entry.Caller.Defined {
+		if final.CallerKey != "" {
+			final.AddString(final.CallerKey, formatCaller(final.EncoderConfig, entry.Caller))
+			final.buf.AppendString(" ")
+		}
+		if final.FunctionKey != "" && entry.Caller.Function != "" {
+			final.AddString(final.FunctionKey, entry.Caller.Function)
+			final.buf.AppendString(" ")
+		}
+	}
 
 	for i := range fields {
 		fields[i].AddTo(final)
 		final.buf.AppendString(" ")
 	}
 	final.buf.AppendString("\n")
+	for _, te := range final.errors {
+		final.buf.AppendString("  " + te.key + ":\n")
+		te.node.appendText(final.buf, "    ")
+	}
+	if entry.Stack != "" && final.StacktraceKey != "" {
+		appendBlock(final.buf, final.StacktraceKey, entry.Stack)
+	}
 	return final.buf, nil
 }
 
@@ -97,6 +175,12 @@ func (e *textEncoder) AddArray(key string, marshaler zapcore.ArrayMarshaler) err
 	return e.AppendArray(marshaler)
 }
 func (e *textEncoder) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	if m, ok := marshaler.(*ErrorMarshaler); ok {
+		// The details of the errors are rendered after the line, the message
+		// is in the error field.
+		e.errors = append(e.errors, textError{key: strings.TrimSuffix(key, ErrorDetailsSuffix), node: m.root})
+		return nil
+	}
 	e.addKey(key)
 	return e.AppendObject(marshaler)
 }
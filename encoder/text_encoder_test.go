@@ -0,0 +1,39 @@
+package encoder
+
+import (
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestTextEncoder_colors(t *testing.T) {
+	enc := NewTextEncoder(zap.NewProductionEncoderConfig())
+	enc.AddString("team", "pki")
+	enc.AddInt("shard", 3)
+
+	tests := []struct {
+		level zapcore.Level
+		color string
+	}{
+		{zapcore.InfoLevel, blue},
+		{zapcore.WarnLevel, yellow},
+		{zapcore.ErrorLevel, red},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			buf, err := enc.EncodeEntry(zapcore.Entry{Level: tt.level, Message: "message"}, []zapcore.Field{zap.String("user", "max")})
+			if err != nil {
+				t.Fatalf("EncodeEntry() error = %v", err)
+			}
+			defer buf.Free()
+			s := buf.String()
+			for _, key := range []string{"team", "shard", "user"} {
+				if want := tt.color + key + colorEnd + "="; !strings.Contains(s, want) {
+					t.Errorf("EncodeEntry() = %q, want it to contain %q", s, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package logging
+
+import (
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// omitKey is the key name used in the Encoding to omit an element of the
+// entries.
+const omitKey = "-"
+
+// Encoding configures the layout of the entries: the keys of the time,
+// message, level, logger name and caller, and the encoding of the time,
+// levels and durations. Settings not configured use the defaults of the
+// format.
+//
+// The JSON format uses all the settings. The text format does not write the
+// keys, but writes the time and the logger name only if their keys are set,
+// and omits the message if its key is "-". The common format does not include
+// the time, message, level or logger name. Both use the caller key and the
+// encoding of the levels, times and durations. By default the text format
+// writes the levels in upper case, both formats write times in RFC 3339, and
+// the common format writes durations in milliseconds. The keys set to "-" are
+// omitted.
+type Encoding struct {
+	// TimeKey is the key of the time of the entries. Defaults to "ts".
+	TimeKey string `json:"timeKey"`
+	// TimeFormat is the encoding of the times: "epoch" for seconds since the
+	// Unix epoch, "epochMillis", "epochNanos", "rfc3339", "rfc3339nano",
+	// "iso8601", or a custom layout like "2006-01-02 15:04:05". Defaults to
+	// "epoch".
+	TimeFormat string `json:"timeFormat"`
+	// TimeZone is the name of the location used to encode the times, like
+	// "UTC", "Local" or "America/Los_Angeles". Defaults to the location of
+	// each time.
+	TimeZone string `json:"timeZone"`
+	// MessageKey is the key of the message. Defaults to "msg".
+	MessageKey string `json:"messageKey"`
+	// LevelKey is the key of the level. Defaults to "level".
+	LevelKey string `json:"levelKey"`
+	// NameKey is the key of the logger name. Defaults to "logger".
+	NameKey string `json:"nameKey"`
+	// CallerKey is the key of the caller. Defaults to "caller".
+	CallerKey string `json:"callerKey"`
+	// LevelCase is the case of the levels: "lower" or "upper". Defaults to
+	// "lower".
+	LevelCase string `json:"levelCase"`
+	// DurationFormat is the encoding of the durations: "string", "seconds",
+	// "millis" or "nanos". Defaults to "seconds".
+	DurationFormat string `json:"durationFormat"`
+}
+
+// WithEncoding configures the layout of the entries.
+func WithEncoding(e *Encoding) Option {
+	return func(o *options) error {
+		o.Encoding = e
+		return nil
+	}
+}
+
+// Validate validates the encoding configuration.
+func (e *Encoding) Validate() error {
+	v := new(validator)
+	e.validate(v, "encoding")
+	return v.err()
+}
+
+func (e *Encoding) validate(v *validator, path string) {
+	if e == nil {
+		return
+	}
+	if e.TimeZone != "" {
+		if _, err := time.LoadLocation(e.TimeZone); err != nil {
+			v.add(path+".timeZone", "unknown time zone '%s'", e.TimeZone)
+		}
+	}
+	switch strings.ToLower(e.LevelCase) {
+	case "", "lower", "upper":
+	default:
+		v.add(path+".levelCase", "unsupported level case '%s'", e.LevelCase)
+	}
+	if _, ok := durationEncoders[strings.ToLower(e.DurationFormat)]; !ok && e.DurationFormat != "" {
+		v.add(path+".durationFormat", "unsupported duration format '%s'", e.DurationFormat)
+	}
+}
+
+var timeEncoders = map[string]zapcore.TimeEncoder{
+	"epoch":       zapcore.EpochTimeEncoder,
+	"epochmillis": zapcore.EpochMillisTimeEncoder,
+	"epochnanos":  zapcore.EpochNanosTimeEncoder,
+	"rfc3339":     zapcore.RFC3339TimeEncoder,
+	"rfc3339nano": zapcore.RFC3339NanoTimeEncoder,
+	"iso8601":     zapcore.ISO8601TimeEncoder,
+}
+
+var durationEncoders = map[string]zapcore.DurationEncoder{
+	"string":  zapcore.StringDurationEncoder,
+	"seconds": zapcore.SecondsDurationEncoder,
+	"millis":  zapcore.MillisDurationEncoder,
+	"nanos":   zapcore.NanosDurationEncoder,
+}
+
+// encodeUpperLevel is a zapcore.LevelEncoder that uses the names of the
+// levels in uppercase, including the trace level.
+func encodeUpperLevel(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	enc.AppendString(strings.ToUpper(Level(l).String()))
+}
+
+// encoderConfig sets the keys and encoders configured in the given
+// configuration.
+func (e *Encoding) encoderConfig(config *zapcore.EncoderConfig) {
+	if e == nil {
+		return
+	}
+	setKey(&config.TimeKey, e.TimeKey)
+	setKey(&config.MessageKey, e.MessageKey)
+	setKey(&config.LevelKey, e.LevelKey)
+	setKey(&config.NameKey, e.NameKey)
+	setKey(&config.CallerKey, e.CallerKey)
+	if strings.EqualFold(e.LevelCase, "upper") {
+		config.EncodeLevel = encodeUpperLevel
+	}
+	if fn, ok := durationEncoders[strings.ToLower(e.DurationFormat)]; ok {
+		config.EncodeDuration = fn
+	}
+	config.EncodeTime = e.timeEncoder(config.EncodeTime)
+}
+
+// timeEncoder returns the configured time encoder, or the given one if no
+// time format is configured, converting the times to the configured time
+// zone.
+func (e *Encoding) timeEncoder(fn zapcore.TimeEncoder) zapcore.TimeEncoder {
+	if e == nil {
+		return fn
+	}
+	if e.TimeFormat != "" {
+		var ok bool
+		if fn, ok = timeEncoders[strings.ToLower(e.TimeFormat)]; !ok {
+			fn = zapcore.TimeEncoderOfLayout(e.TimeFormat)
+		}
+	}
+	if e.TimeZone == "" {
+		return fn
+	}
+	loc, err := time.LoadLocation(e.TimeZone)
+	if err != nil {
+		return fn
+	}
+	return func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+		fn(t.In(loc), enc)
+	}
+}
+
+// formatConfig returns the encoder configuration used by the given format,
+// restoring the defaults of the text and common formats for the settings
+// that are not configured. The text format omits the time and logger name
+// unless their keys are configured.
+func (e *Encoding) formatConfig(format string, config zapcore.EncoderConfig) zapcore.EncoderConfig {
+	var c Encoding
+	if e != nil {
+		c = *e
+	}
+	switch strings.ToLower(format) {
+	case "", "text", "docker":
+		if c.LevelCase == "" {
+			config.EncodeLevel = encodeUpperLevel
+		}
+		if c.TimeKey == "" {
+			config.TimeKey = zapcore.OmitKey
+		}
+		if c.NameKey == "" {
+			config.NameKey = zapcore.OmitKey
+		}
+		if c.TimeFormat == "" {
+			config.EncodeTime = e.timeEncoder(zapcore.RFC3339TimeEncoder)
+		}
+	case "common":
+		if c.TimeFormat == "" {
+			config.EncodeTime = e.timeEncoder(zapcore.RFC3339TimeEncoder)
+		}
+		if c.DurationFormat == "" {
+			config.EncodeDuration = nil
+		}
+	}
+	return config
+}
+
+func setKey(key *string, value string) {
+	switch value {
+	case "":
+	case omitKey:
+		*key = zapcore.OmitKey
+	default:
+		*key = value
+	}
+}
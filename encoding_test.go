@@ -0,0 +1,115 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smallstep/logging/internal/testclock"
+	"go.uber.org/zap"
+)
+
+func TestLogger_encoding(t *testing.T) {
+	clock := testclock.New(time.Date(2024, 1, 2, 3, 4, 5, 6000000, time.UTC))
+	encoding := &Encoding{
+		TimeKey:        "time",
+		TimeFormat:     "rfc3339nano",
+		TimeZone:       "America/New_York",
+		MessageKey:     "message",
+		LevelKey:       "severity",
+		NameKey:        "-",
+		LevelCase:      "upper",
+		DurationFormat: "string",
+	}
+
+	var jsonOut, textOut, clfOut bytes.Buffer
+	logger, err := New("test",
+		WithSink("json", &jsonOut, AllLevels(), "json"),
+		WithSink("text", &textOut, AllLevels(), "text"),
+		WithSink("common", &clfOut, AllLevels(), "common"),
+		WithClock(clock),
+		WithEncoding(encoding),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	logger.Warn("warn message", zap.Duration("duration", 1500*time.Millisecond), zap.Time("start", clock.Now()))
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(jsonOut.Bytes(), &entry); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	want := map[string]interface{}{
+		"time":     "2024-01-01T22:04:05.006-05:00",
+		"severity": "WARN",
+		"message":  "warn message",
+		"duration": "1.5s",
+		"start":    "2024-01-01T22:04:05.006-05:00",
+	}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("entry[%q] = %v, want %v", k, entry[k], v)
+		}
+	}
+	if _, ok := entry["logger"]; ok {
+		t.Error("entry has a logger name")
+	}
+
+	if s := textOut.String(); !strings.HasPrefix(s, "2024-01-01T22:04:05.006-05:00 ") || !strings.Contains(s, "WARN") || !strings.Contains(s, "warn message") || !strings.Contains(s, "=1.5s ") || !strings.Contains(s, "=2024-01-01T22:04:05.006-05:00 ") {
+		t.Errorf("text output = %q", s)
+	}
+	if s := clfOut.String(); !strings.Contains(s, " 1.5s ") {
+		t.Errorf("common output = %q", s)
+	}
+}
+
+func TestLogger_encodingDefaults(t *testing.T) {
+	var textOut, clfOut bytes.Buffer
+	logger, err := New("test",
+		WithSink("text", &textOut, AllLevels(), "text"),
+		WithSink("common", &clfOut, AllLevels(), "common"),
+		WithEncoding(&Encoding{TimeZone: "UTC"}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	logger.Info("info message", zap.Duration("duration", 1500*time.Millisecond), zap.Time("time", time.Unix(0, 0)))
+
+	if s := textOut.String(); !strings.HasPrefix(s, "\x1b[36mINFO") {
+		t.Errorf("text output = %q", s)
+	}
+	if s := clfOut.String(); !strings.Contains(s, " 1970-01-01T00:00:00Z 1500 ") {
+		t.Errorf("common output = %q", s)
+	}
+}
+
+func TestLogger_encodingText(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New("test",
+		WithSink("text", &out, AllLevels(), "text"),
+		WithEncoding(&Encoding{NameKey: "name", MessageKey: "-"}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	logger.Named("child").Info("hidden message", zap.Int("shard", 3))
+
+	s := out.String()
+	if !strings.Contains(s, "INFO\x1b[0m test.child ") || strings.Contains(s, "hidden message") || !strings.Contains(s, "=3 ") {
+		t.Errorf("text output = %q", s)
+	}
+}
+
+func TestEncoding_Validate(t *testing.T) {
+	e := &Encoding{TimeZone: "Mars/Olympus", LevelCase: "title", DurationFormat: "hours"}
+	var errs ValidationErrors
+	if err := e.Validate(); !errors.As(err, &errs) || len(errs) != 3 {
+		t.Fatalf("Encoding.Validate() error = %v", err)
+	}
+	if err := (&Encoding{TimeFormat: "2006-01-02", LevelCase: "Upper", DurationFormat: "millis"}).Validate(); err != nil {
+		t.Errorf("Encoding.Validate() error = %v", err)
+	}
+}
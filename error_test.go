@@ -0,0 +1,104 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+func testError() error {
+	return errors.Wrap(stderrors.Join(
+		stderrors.New("not found"),
+		errors.New("boom"),
+	), "request failed")
+}
+
+func TestLogger_errors(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New("test", WithSink("out", &out, AllLevels(), "json"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	logger.Error("error message", zap.Error(testError()), zap.NamedError("plain", stderrors.New("plain error")))
+
+	var entry struct {
+		Error        string `json:"error"`
+		ErrorDetails struct {
+			Message string   `json:"message"`
+			Stack   []string `json:"stack"`
+			Causes  []struct {
+				Type   string `json:"type"`
+				Causes []struct {
+					Message string   `json:"message"`
+					Stack   []string `json:"stack"`
+				} `json:"causes"`
+			} `json:"causes"`
+		} `json:"errorDetails"`
+		Plain        string          `json:"plain"`
+		PlainDetails json.RawMessage `json:"plainDetails"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	// The error field keeps the message, the rest is in the details.
+	if entry.Error != "request failed: not found\nboom" {
+		t.Errorf("error = %q", entry.Error)
+	}
+	if entry.Plain != "plain error" || entry.PlainDetails != nil {
+		t.Errorf("plain = %q, plainDetails = %s", entry.Plain, entry.PlainDetails)
+	}
+	e := entry.ErrorDetails
+	if e.Message != entry.Error || len(e.Stack) == 0 || len(e.Causes) != 1 {
+		t.Fatalf("unexpected error %+v", e)
+	}
+	if join := e.Causes[0]; join.Type != "*errors.joinError" || len(join.Causes) != 2 {
+		t.Fatalf("unexpected cause %+v", join)
+	}
+	causes := e.Causes[0].Causes
+	if causes[0].Message != "not found" {
+		t.Errorf("unexpected cause %+v", causes[0])
+	}
+	if causes[1].Message != "boom" || len(causes[1].Stack) == 0 || !strings.Contains(causes[1].Stack[0], "logging.testError") {
+		t.Errorf("unexpected pkg/errors cause %+v", causes[1])
+	}
+}
+
+func TestLogger_errorsText(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New("test", WithSink("out", &out, AllLevels(), "text"), WithErrorMaxDepth(1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	logger.Error("error message", zap.Error(testError()))
+
+	// The message of the joined errors is written as is.
+	lines := strings.Split(out.String(), "\n")
+	if !strings.HasSuffix(lines[0], "=request failed: not found") || strings.TrimSpace(lines[1]) != "boom" {
+		t.Errorf("unexpected lines %q", lines[:2])
+	}
+	want := []string{
+		"  error:",
+		"    *errors.withMessage: request failed: not found",
+		"      boom",
+		"      at github.com/smallstep/logging.testError ",
+		"      caused by:",
+		"        *errors.joinError: not found",
+		"          boom",
+		"          ...",
+	}
+	var i int
+	for _, line := range lines[1:] {
+		if i < len(want) && strings.HasPrefix(line, want[i]) {
+			i++
+		}
+	}
+	if i != len(want) {
+		t.Errorf("line %q not found in %s", want[i], out.String())
+	}
+}
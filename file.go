@@ -0,0 +1,407 @@
+package logging
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// backupTimeFormat is the format used in the name of the rotated files.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// compressSuffix is the suffix added to the compressed files.
+const compressSuffix = ".gz"
+
+// Rotation defines when a file is rotated, and how many rotated files are kept.
+type Rotation struct {
+	// MaxSize is the maximum size in bytes of a file before it gets rotated.
+	MaxSize int64 `json:"maxSize"`
+	// Interval is the duration after which a file gets rotated. Files are
+	// rotated on the boundaries of the interval, for example, an interval of
+	// 24h rotates files at midnight UTC.
+	Interval Duration `json:"interval"`
+	// MaxAge is the maximum time to retain rotated files.
+	MaxAge Duration `json:"maxAge"`
+	// MaxBackups is the maximum number of rotated files to retain.
+	MaxBackups int `json:"maxBackups"`
+	// Compress enables the gzip compression of the rotated files.
+	Compress bool `json:"compress"`
+	// LocalTime uses the local time instead of UTC in the name of the rotated
+	// files.
+	LocalTime bool `json:"localTime"`
+}
+
+// FileWriter is an io.Writer that writes to a file, rotating it by size and
+// time. Rotated files are renamed to <name>-<timestamp><ext>, or
+// <name>-<timestamp>.<n><ext> if a file was already rotated in the same
+// millisecond, optionally compressed, and removed according to the retention
+// policy.
+type FileWriter struct {
+	mu       sync.Mutex
+	path     string
+	rotation Rotation
+	file     *os.File
+	closed   bool
+	size     int64
+	period   time.Time
+	now      func() time.Time
+	millCh   chan struct{}
+	millDone chan struct{}
+}
+
+// NewFileWriter opens or creates the file in the given path and returns a
+// FileWriter that rotates it with the given policy.
+func NewFileWriter(path string, rotation Rotation) (*FileWriter, error) {
+	return newFileWriter(path, rotation, time.Now)
+}
+
+func newFileWriter(path string, rotation Rotation, now func() time.Time) (*FileWriter, error) {
+	switch {
+	case rotation.MaxSize < 0:
+		return nil, errors.New("logger rotation maxSize cannot be negative")
+	case rotation.Interval.Duration < 0:
+		return nil, errors.New("logger rotation interval cannot be negative")
+	case rotation.MaxAge.Duration < 0:
+		return nil, errors.New("logger rotation maxAge cannot be negative")
+	case rotation.MaxBackups < 0:
+		return nil, errors.New("logger rotation maxBackups cannot be negative")
+	}
+
+	w := &FileWriter{
+		path:     path,
+		rotation: rotation,
+		now:      now,
+		millCh:   make(chan struct{}, 1),
+		millDone: make(chan struct{}),
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+
+	go w.millRun()
+	w.mill()
+
+	return w, nil
+}
+
+// Write implements io.Writer. It rotates the file before writing if the write
+// would exceed the maximum size, or if the rotation interval has elapsed.
+func (w *FileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	// Reopen the file if a previous rotation failed to do it.
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if w.shouldRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Sync commits the current contents of the file to stable storage.
+func (w *FileWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+// Rotate closes the current file, renames it and opens a new one.
+func (w *FileWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return os.ErrClosed
+	}
+	if w.file == nil {
+		return w.open()
+	}
+	return w.rotate()
+}
+
+// Close closes the file and waits for any pending compression.
+func (w *FileWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.mu.Unlock()
+
+	close(w.millCh)
+	<-w.millDone
+	return err
+}
+
+// shouldRotate returns true if the file must be rotated before writing n
+// bytes. An empty file is not rotated when the interval elapses, it starts the
+// current period instead, so no empty backups are created.
+func (w *FileWriter) shouldRotate(n int64) bool {
+	if w.rotation.MaxSize > 0 && w.size > 0 && w.size+n > w.rotation.MaxSize {
+		return true
+	}
+	if d := w.rotation.Interval.Duration; d > 0 {
+		if period := w.now().Truncate(d); !period.Equal(w.period) {
+			if w.size == 0 {
+				w.period = period
+				return false
+			}
+			return true
+		}
+	}
+	return false
+}
+
+func (w *FileWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.path), 0700); err != nil {
+		return errors.Wrapf(err, "error creating directory for %s", w.path)
+	}
+
+	f, err := os.OpenFile(w.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "error opening %s", w.path)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return errors.Wrapf(err, "error opening %s", w.path)
+	}
+
+	w.file = f
+	w.size = fi.Size()
+	w.period = w.now()
+	if d := w.rotation.Interval.Duration; d > 0 {
+		// Existing files belong to the period of their last write.
+		if w.size > 0 {
+			w.period = fi.ModTime()
+		}
+		w.period = w.period.Truncate(d)
+	}
+	return nil
+}
+
+func (w *FileWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return errors.Wrapf(err, "error closing %s", w.path)
+	}
+	w.file = nil
+
+	if err := os.Rename(w.path, w.backupName(w.now())); err != nil {
+		// Keep writing to the current file.
+		if e := w.open(); e != nil {
+			return e
+		}
+		return errors.Wrapf(err, "error rotating %s", w.path)
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	w.mill()
+	return nil
+}
+
+// backupName returns the name of the rotated file. A sequence number is added
+// if a file with the same timestamp already exists, so it's not overwritten.
+func (w *FileWriter) backupName(t time.Time) string {
+	if !w.rotation.LocalTime {
+		t = t.UTC()
+	}
+	dir, prefix, ext := w.nameParts()
+	base := prefix + t.Format(backupTimeFormat)
+	name := filepath.Join(dir, base+ext)
+	for seq := 1; fileExists(name) || fileExists(name+compressSuffix); seq++ {
+		name = filepath.Join(dir, base+"."+strconv.Itoa(seq)+ext)
+	}
+	return name
+}
+
+func fileExists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+func (w *FileWriter) nameParts() (dir, prefix, ext string) {
+	dir = filepath.Dir(w.path)
+	filename := filepath.Base(w.path)
+	ext = filepath.Ext(filename)
+	prefix = strings.TrimSuffix(filename, ext) + "-"
+	return
+}
+
+// mill signals the background goroutine to compress and remove the rotated
+// files. It does not block if there's a pending signal.
+func (w *FileWriter) mill() {
+	select {
+	case w.millCh <- struct{}{}:
+	default:
+	}
+}
+
+func (w *FileWriter) millRun() {
+	defer close(w.millDone)
+	for range w.millCh {
+		_ = w.millRunOnce()
+	}
+}
+
+type backupFile struct {
+	path      string
+	timestamp time.Time
+	seq       int
+}
+
+// millRunOnce removes the rotated files that exceed the retention policy and
+// compresses the remaining ones if enabled.
+func (w *FileWriter) millRunOnce() error {
+	if w.rotation.MaxBackups == 0 && w.rotation.MaxAge.Duration == 0 && !w.rotation.Compress {
+		return nil
+	}
+
+	backups, err := w.backups()
+	if err != nil {
+		return err
+	}
+
+	var remove, compress []backupFile
+	cutoff := w.now().Add(-w.rotation.MaxAge.Duration)
+	for i, b := range backups {
+		switch {
+		case w.rotation.MaxBackups > 0 && i >= w.rotation.MaxBackups:
+			remove = append(remove, b)
+		case w.rotation.MaxAge.Duration > 0 && b.timestamp.Before(cutoff):
+			remove = append(remove, b)
+		case w.rotation.Compress && !strings.HasSuffix(b.path, compressSuffix):
+			compress = append(compress, b)
+		}
+	}
+
+	for _, b := range remove {
+		if e := os.Remove(b.path); e != nil && err == nil {
+			err = e
+		}
+	}
+	for _, b := range compress {
+		if e := compressFile(b.path); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// backups returns the rotated files sorted from newest to oldest.
+func (w *FileWriter) backups() ([]backupFile, error) {
+	dir, prefix, ext := w.nameParts()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := time.UTC
+	if w.rotation.LocalTime {
+		loc = time.Local
+	}
+
+	var backups []backupFile
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		ts := strings.TrimSuffix(strings.TrimPrefix(name, prefix), compressSuffix)
+		if !strings.HasSuffix(ts, ext) {
+			continue
+		}
+		ts = strings.TrimSuffix(ts, ext)
+		if len(ts) < len(backupTimeFormat) {
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, ts[:len(backupTimeFormat)], loc)
+		if err != nil {
+			continue
+		}
+		var seq int
+		if s := ts[len(backupTimeFormat):]; s != "" {
+			if seq, err = strconv.Atoi(strings.TrimPrefix(s, ".")); err != nil || s[0] != '.' || seq < 1 {
+				continue
+			}
+		}
+		backups = append(backups, backupFile{
+			path:      filepath.Join(dir, name),
+			timestamp: t,
+			seq:       seq,
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].timestamp.Equal(backups[j].timestamp) {
+			return backups[i].timestamp.After(backups[j].timestamp)
+		}
+		return backups[i].seq > backups[j].seq
+	})
+	return backups, nil
+}
+
+// compressFile compresses the given file with gzip and removes the original.
+func compressFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+compressSuffix, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			dst.Close()
+			os.Remove(path + compressSuffix)
+		}
+	}()
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	src.Close()
+	return os.Remove(path)
+}
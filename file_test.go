@@ -0,0 +1,215 @@
+package logging
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smallstep/logging/internal/testclock"
+)
+
+func readDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.Name()
+	}
+	return names
+}
+
+func TestFileWriter_maxSize(t *testing.T) {
+	dir := t.TempDir()
+	clock := testclock.New(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	w, err := newFileWriter(filepath.Join(dir, "app.log"), Rotation{MaxSize: 10, MaxBackups: 2}, clock.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 4; i++ {
+		clock.Add(time.Second)
+		if _, err := w.Write([]byte("0123456789")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Run the retention policy after the last rotation.
+	if err := w.millRunOnce(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"app-2024-01-02T03-04-08.000.log", "app-2024-01-02T03-04-09.000.log", "app.log"}
+	if got := readDir(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+}
+
+func TestFileWriter_interval(t *testing.T) {
+	dir := t.TempDir()
+	clock := testclock.New(time.Date(2024, 1, 2, 23, 59, 0, 0, time.UTC))
+
+	w, err := newFileWriter(filepath.Join(dir, "app.log"), Rotation{
+		Interval: Duration{24 * time.Hour},
+		MaxAge:   Duration{48 * time.Hour},
+		Compress: true,
+	}, clock.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{"day 2\n", "day 3\n", "day 4\n", "day 5\n"} {
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+		clock.Add(24 * time.Hour)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Run the retention policy after the last rotation.
+	if err := w.millRunOnce(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"app-2024-01-04T23-59-00.000.log.gz", "app-2024-01-05T23-59-00.000.log.gz", "app.log"}
+	if got := readDir(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("files = %v, want %v", got, want)
+	}
+
+	f, err := os.Open(filepath.Join(dir, want[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "day 4\n" {
+		t.Errorf("compressed content = %q, want %q", b, "day 4\n")
+	}
+}
+
+func TestFileWriter_intervalEmpty(t *testing.T) {
+	dir := t.TempDir()
+	clock := testclock.New(time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC))
+
+	w, err := newFileWriter(filepath.Join(dir, "app.log"), Rotation{
+		Interval: Duration{24 * time.Hour},
+	}, clock.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing is written in the first two periods.
+	clock.Add(48 * time.Hour)
+	if _, err := w.Write([]byte("day 3\n")); err != nil {
+		t.Fatal(err)
+	}
+	clock.Add(24 * time.Hour)
+	if _, err := w.Write([]byte("day 4\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"app-2024-01-05T12-00-00.000.log", "app.log"}
+	if got := readDir(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("files = %v, want %v", got, want)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, want[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "day 3\n" {
+		t.Errorf("backup content = %q, want %q", b, "day 3\n")
+	}
+}
+
+func TestFileWriter_sameTimestamp(t *testing.T) {
+	dir := t.TempDir()
+	clock := testclock.New(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	w, err := newFileWriter(filepath.Join(dir, "app.log"), Rotation{MaxBackups: 2}, clock.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"first\n", "second\n", "third\n"} {
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.millRunOnce(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"app-2024-01-02T03-04-05.000.1.log", "app-2024-01-02T03-04-05.000.2.log", "app.log"}
+	if got := readDir(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("files = %v, want %v", got, want)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, want[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "third\n" {
+		t.Errorf("backup content = %q, want %q", b, "third\n")
+	}
+}
+
+func TestFileWriter_reopenError(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewFileWriter(filepath.Join(dir, "app.log"), Rotation{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Replace the directory with a file so the log can't be reopened.
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dir, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Rotate(); err == nil {
+		t.Fatal("Rotate() error = nil")
+	}
+	if _, err := w.Write([]byte("message\n")); err == nil {
+		t.Error("Write() error = nil")
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-w.millDone:
+	case <-time.After(time.Second):
+		t.Fatal("mill goroutine is still running")
+	}
+	if _, err := w.Write([]byte("message\n")); err != os.ErrClosed {
+		t.Errorf("Write() error = %v, want %v", err, os.ErrClosed)
+	}
+}
@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"os"
+
+	"github.com/smallstep/logging/encoder"
+	"go.uber.org/zap/zapcore"
+)
+
+// GCP configures the "gcp" format, the structured format of Google Cloud
+// Logging.
+type GCP struct {
+	// ProjectID is the Google Cloud project used in the resource name of the
+	// traces, "projects/<project-id>/traces/<trace-id>". Defaults to the
+	// GOOGLE_CLOUD_PROJECT environment variable.
+	ProjectID string `json:"projectId"`
+}
+
+// WithGCP configures the "gcp" format.
+func WithGCP(g *GCP) Option {
+	return func(o *options) error {
+		o.GCP = g
+		return nil
+	}
+}
+
+func (g *GCP) projectID() string {
+	if g == nil || g.ProjectID == "" {
+		return os.Getenv("GOOGLE_CLOUD_PROJECT")
+	}
+	return g.ProjectID
+}
+
+// newGCPEncoder returns the encoder used by a sink with the gcp format.
+func newGCPEncoder(g *GCP, config zapcore.EncoderConfig) zapcore.Encoder {
+	return encoder.NewGCPEncoder(config, encoder.GCPConfig{
+		ProjectID: g.projectID(),
+	})
+}
@@ -0,0 +1,110 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var globalLogger atomic.Pointer[Logger]
+
+// nopLogger returns a logger that discards all the entries, it is used by L
+// if no default logger is set.
+var nopLogger = sync.OnceValue(func() *Logger {
+	o := defaultOptions()
+	return &Logger{
+		Logger:  zap.NewNop(),
+		options: o,
+		levels:  newLevelSet(o.Level, nil, nil),
+	}
+})
+
+// SetDefault sets the logger returned by L. A nil logger restores the default
+// one, a logger that discards all the entries.
+func SetDefault(l *Logger) {
+	globalLogger.Store(l)
+}
+
+// L returns the logger set with SetDefault, or a logger that discards all the
+// entries if none is set. It is safe for concurrent use.
+func L() *Logger {
+	if l := globalLogger.Load(); l != nil {
+		return l
+	}
+	return nopLogger()
+}
+
+// RedirectOption is the type used to modify the options of RedirectStdLog.
+type RedirectOption func(o *redirectOptions)
+
+type redirectOptions struct {
+	slog bool
+}
+
+// WithSlogDefault makes RedirectStdLog set the default handler of the log/slog
+// package to the handler of the logger too.
+func WithSlogDefault() RedirectOption {
+	return func(o *redirectOptions) {
+		o.slog = true
+	}
+}
+
+// RedirectStdLog redirects the output of the default logger of the standard
+// log package to the logger at the given level. The trailing new lines are
+// removed and the caller annotation points to the caller of the log functions.
+// The flags and prefix of the standard logger are cleared while redirected.
+//
+// It returns a function that restores the previous output, flags and prefix,
+// and the previous default slog handler if WithSlogDefault is used.
+func (l *Logger) RedirectStdLog(level Level, opts ...RedirectOption) func() {
+	o := new(redirectOptions)
+	for _, fn := range opts {
+		fn(o)
+	}
+
+	flags, prefix, output := log.Flags(), log.Prefix(), log.Writer()
+	var prevSlog *slog.Logger
+	if o.slog {
+		prevSlog = slog.Default()
+		// slog.SetDefault also redirects the log package, so it must be set
+		// before the log output.
+		slog.SetDefault(slog.New(l.SlogHandler()))
+	}
+
+	log.SetFlags(0)
+	log.SetPrefix("")
+	log.SetOutput(&stdLogWriter{
+		// The caller skip of the logger covers the Write method, skip the
+		// log.(*Logger).output and log.Printf frames too.
+		logger: l.Logger.WithOptions(zap.AddCallerSkip(2)),
+		level:  zapcore.Level(level),
+	})
+
+	return func() {
+		if o.slog {
+			slog.SetDefault(prevSlog)
+		}
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+		log.SetOutput(output)
+	}
+}
+
+// stdLogWriter is the io.Writer used to redirect the standard log package.
+type stdLogWriter struct {
+	logger *zap.Logger
+	level  zapcore.Level
+}
+
+func (w *stdLogWriter) Write(b []byte) (int, error) {
+	msg := string(bytes.TrimRight(b, "\n"))
+	if ce := w.logger.Check(w.level, msg); ce != nil {
+		ce.Write()
+	}
+	return len(b), nil
+}
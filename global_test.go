@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestL(t *testing.T) {
+	t.Cleanup(func() { SetDefault(nil) })
+
+	// The default logger discards the entries.
+	L().Info("discarded")
+	if L().Level() != levelFromEnv() {
+		t.Errorf("L().Level() = %v", L().Level())
+	}
+
+	var out bytes.Buffer
+	logger, err := New("test", WithSink("out", &out, AllLevels(), "json"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	SetDefault(logger)
+	if L() != logger {
+		t.Fatal("L() did not return the default logger")
+	}
+	L().Info("message")
+	if !strings.Contains(out.String(), `"msg":"message"`) {
+		t.Errorf("output = %q", out.String())
+	}
+}
+
+func TestLogger_RedirectStdLog(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New("test",
+		WithSink("out", &out, AllLevels(), "json"),
+		WithCaller(&Caller{Function: true}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	log.SetPrefix("prefix: ")
+	prevSlog := slog.Default()
+	t.Cleanup(func() {
+		log.SetFlags(log.LstdFlags)
+		log.SetPrefix("")
+	})
+
+	undo := logger.RedirectStdLog(WarnLevel, WithSlogDefault())
+	log.Println("std message")
+	slog.Info("slog message")
+	undo()
+
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v", err)
+		}
+		entries = append(entries, m)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2: %s", len(entries), out.String())
+	}
+	if entries[0]["msg"] != "std message" || entries[0]["level"] != "warn" {
+		t.Errorf("std entry = %v", entries[0])
+	}
+	if entries[1]["msg"] != "slog message" || entries[1]["level"] != "info" {
+		t.Errorf("slog entry = %v", entries[1])
+	}
+	for _, e := range entries {
+		if e["function"] != "github.com/smallstep/logging.TestLogger_RedirectStdLog" {
+			t.Errorf("function = %v", e["function"])
+		}
+	}
+
+	if log.Flags() != log.LstdFlags|log.Lshortfile || log.Prefix() != "prefix: " {
+		t.Errorf("log flags = %d, prefix = %q", log.Flags(), log.Prefix())
+	}
+	if slog.Default() != prevSlog {
+		t.Error("slog default was not restored")
+	}
+}
@@ -0,0 +1,32 @@
+package grpclog
+
+import (
+	"fmt"
+
+	"google.golang.org/grpc/status"
+
+	"github.com/smallstep/logging/encoder"
+)
+
+func init() {
+	encoder.RegisterErrorCoder(statusErrorCode)
+}
+
+// statusErrorCode is the encoder.ErrorCoder that adds the code and details of
+// gRPC status errors to the rendered errors. It's registered when the package
+// is imported.
+func statusErrorCode(err error) (string, []string, bool) {
+	se, ok := err.(interface{ GRPCStatus() *status.Status })
+	if !ok {
+		return "", nil, false
+	}
+	s := se.GRPCStatus()
+	if s == nil {
+		return "", nil, false
+	}
+	var details []string
+	for _, d := range s.Details() {
+		details = append(details, fmt.Sprint(d))
+	}
+	return s.Code().String(), details, true
+}
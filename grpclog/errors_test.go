@@ -0,0 +1,68 @@
+package grpclog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/smallstep/logging"
+)
+
+func TestStatusErrorCode(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := logging.New("test", logging.WithSink("out", &out, logging.AllLevels(), "json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = pkgerrors.Wrap(errors.Join(
+		status.Error(codes.NotFound, "not found"),
+		errors.New("boom"),
+	), "request failed")
+	logger.Error("error message", zap.Error(err))
+
+	var entry struct {
+		Error        string `json:"error"`
+		ErrorDetails struct {
+			Code   string `json:"code"`
+			Causes []struct {
+				Causes []struct {
+					Message string `json:"message"`
+					Code    string `json:"code"`
+				} `json:"causes"`
+			} `json:"causes"`
+		} `json:"errorDetails"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if entry.Error != "request failed: rpc error: code = NotFound desc = not found\nboom" {
+		t.Errorf("error = %q", entry.Error)
+	}
+	// Only the status error has a code, not the errors wrapping it.
+	if d := entry.ErrorDetails; d.Code != "" || len(d.Causes) != 1 || len(d.Causes[0].Causes) != 2 {
+		t.Fatalf("unexpected details %+v", d)
+	}
+	causes := entry.ErrorDetails.Causes[0].Causes
+	if causes[0].Code != "NotFound" || causes[0].Message != "rpc error: code = NotFound desc = not found" {
+		t.Errorf("unexpected status cause %+v", causes[0])
+	}
+	if causes[1].Code != "" {
+		t.Errorf("unexpected cause %+v", causes[1])
+	}
+}
+
+func TestStatusErrorCode_plain(t *testing.T) {
+	if _, _, ok := statusErrorCode(errors.New("plain")); ok {
+		t.Error("statusErrorCode() ok = true, want false")
+	}
+	code, _, ok := statusErrorCode(status.Error(codes.Unavailable, "unavailable"))
+	if !ok || code != "Unavailable" {
+		t.Errorf("statusErrorCode() = %q, %v, want Unavailable, true", code, ok)
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"go.uber.org/zap"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"github.com/smallstep/logging"
 	"github.com/smallstep/logging/tracing"
@@ -22,6 +24,37 @@ const (
 	streamType
 )
 
+type options struct {
+	recovery RecoveryFunc
+}
+
+// Option is the type used to modify the interceptor options.
+type Option func(o *options)
+
+func (o *options) apply(opts []Option) *options {
+	for _, fn := range opts {
+		fn(o)
+	}
+	return o
+}
+
+// RecoveryFunc is a function that returns the error of a call whose handler
+// panicked. The value is the one passed to panic.
+type RecoveryFunc func(ctx context.Context, v interface{}) error
+
+// WithRecovery is an option that sets the function used to create the error
+// returned after a panic. Defaults to DefaultRecovery.
+func WithRecovery(fn RecoveryFunc) Option {
+	return func(o *options) {
+		o.recovery = fn
+	}
+}
+
+// DefaultRecovery returns an error with the codes.Internal code.
+func DefaultRecovery(context.Context, interface{}) error {
+	return status.Error(codes.Internal, "internal error")
+}
+
 // TracingContext gets the tracing id from the context metadata or generates a
 // new one and appends it to the context.
 func TracingContext(ctx context.Context, traceHeader string) context.Context {
@@ -54,13 +87,14 @@ func TracingContext(ctx context.Context, traceHeader string) context.Context {
 }
 
 // UnaryServerInterceptor returns a new unary server interceptors for logging
-// unary requests.
-func UnaryServerInterceptor(logger *logging.Logger) grpc.UnaryServerInterceptor {
-	l := newServerLogger(logger, unaryType)
+// unary requests. Panics in the handler are logged and recovered, and
+// converted to an error using the recovery function.
+func UnaryServerInterceptor(logger *logging.Logger, opts ...Option) grpc.UnaryServerInterceptor {
+	l := newServerLogger(logger, unaryType, opts)
 	traceHeader := strings.ToLower(logger.TraceHeader())
 
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		t1 := time.Now()
+		t1 := logger.Now()
 
 		// Get or set the traceparent
 		ctx = TracingContext(ctx, traceHeader)
@@ -71,8 +105,11 @@ func UnaryServerInterceptor(logger *logging.Logger) grpc.UnaryServerInterceptor
 		}
 
 		// Call handler
-		resp, err := handler(ctx, req)
-		duration := time.Since(t1)
+		resp, err := func() (_ interface{}, err error) {
+			defer l.recover(ctx, info.FullMethod, &err)
+			return handler(ctx, req)
+		}()
+		duration := logger.Now().Sub(t1)
 
 		if logger.LogRequests() {
 			if p, ok := req.(proto.Message); ok {
@@ -93,13 +130,14 @@ func UnaryServerInterceptor(logger *logging.Logger) grpc.UnaryServerInterceptor
 }
 
 // StreamServerInterceptor returns a new streaming server interceptor for
-// logging stream requests.
-func StreamServerInterceptor(logger *logging.Logger) grpc.StreamServerInterceptor {
-	l := newServerLogger(logger, streamType)
+// logging stream requests. Panics in the handler are logged and recovered, and
+// converted to an error using the recovery function.
+func StreamServerInterceptor(logger *logging.Logger, opts ...Option) grpc.StreamServerInterceptor {
+	l := newServerLogger(logger, streamType, opts)
 	traceHeader := strings.ToLower(logger.TraceHeader())
 
 	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		t1 := time.Now()
+		t1 := logger.Now()
 
 		// Get or set the traceparent
 		ctx := TracingContext(stream.Context(), traceHeader)
@@ -113,8 +151,11 @@ func StreamServerInterceptor(logger *logging.Logger) grpc.StreamServerIntercepto
 		wrapped := newServerStream(ctx, info.FullMethod, stream, l)
 
 		// Call handler
-		err := handler(srv, wrapped)
-		duration := time.Since(t1)
+		err := func() (err error) {
+			defer l.recover(ctx, info.FullMethod, &err)
+			return handler(srv, wrapped)
+		}()
+		duration := logger.Now().Sub(t1)
 
 		// Write log
 		l.Log(ctx, info.FullMethod, t1, duration, fields, err)
@@ -0,0 +1,67 @@
+package grpclog_test
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/smallstep/logging"
+	"github.com/smallstep/logging/grpclog"
+	"github.com/smallstep/logging/logtest"
+)
+
+func TestGRPCRecovery(t *testing.T) {
+	logger, rec := logtest.New("test")
+
+	interceptor := grpclog.UnaryServerInterceptor(logger)
+	_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{
+		FullMethod: "/foo.bar.Service/Method",
+	}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("boom")
+	})
+	if status.Code(err) != codes.Internal {
+		t.Errorf("error = %v, want code Internal", err)
+	}
+
+	panics := rec.All().FilterMessage("panic recovered")
+	if logtest.AssertCount(t, panics, 1) {
+		if e := panics[0]; e.Level != logging.ErrorLevel || e.String("panic") != "boom" ||
+			e.String("grpc.method") != "Method" || e.Stack == "" || e.String("system") != "" {
+			t.Errorf("unexpected entry %v", e)
+		}
+	}
+	entries := rec.All().GRPC().FilterField("grpc.code", "Internal")
+	if logtest.AssertCount(t, entries, 1) {
+		logtest.AssertGRPC(t, entries[0], logtest.GRPCEntry{
+			Name:    "test",
+			Service: "Service",
+			Method:  "Method",
+			Code:    "Internal",
+		})
+	}
+
+	// Custom errors
+	stream := grpclog.StreamServerInterceptor(logger, grpclog.WithRecovery(func(ctx context.Context, v interface{}) error {
+		return status.Errorf(codes.Unavailable, "%v", v)
+	}))
+	err = stream(nil, &testServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{
+		FullMethod: "/foo.bar.Service/Stream",
+	}, func(srv interface{}, stream grpc.ServerStream) error {
+		panic("boom")
+	})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("error = %v, want code Unavailable", err)
+	}
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
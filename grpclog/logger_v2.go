@@ -0,0 +1,210 @@
+package grpclog
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	grpclogv2 "google.golang.org/grpc/grpclog"
+
+	"github.com/smallstep/logging"
+)
+
+// LoggerV2 implements the grpclog.LoggerV2 and grpclog.DepthLoggerV2
+// interfaces of gRPC on top of a logging.Logger, so the internal diagnostics
+// of gRPC are written as structured entries.
+//
+// Messages tagged with a gRPC component, like "[transport]", are written
+// using a child logger named after the component, so their levels can be
+// configured with names like "grpc.transport".
+type LoggerV2 struct {
+	logger     *logging.Logger
+	verbosity  int
+	components sync.Map // map[componentKey]*logging.Logger
+}
+
+// componentKey identifies the logger used for the messages of a component
+// written at a call depth.
+type componentKey struct {
+	component string
+	depth     int
+}
+
+var (
+	_ grpclogv2.LoggerV2      = (*LoggerV2)(nil)
+	_ grpclogv2.DepthLoggerV2 = (*LoggerV2)(nil)
+)
+
+// LoggerV2Option is the type used to modify the LoggerV2 options.
+type LoggerV2Option func(l *LoggerV2)
+
+// WithVerbosity sets the verbosity of the LoggerV2, V(l) reports true for
+// the levels lower or equal to it. Defaults to the value of the
+// GRPC_GO_LOG_VERBOSITY_LEVEL environment variable, or 0.
+func WithVerbosity(v int) LoggerV2Option {
+	return func(l *LoggerV2) {
+		l.verbosity = v
+	}
+}
+
+// NewLoggerV2 returns a LoggerV2 that writes to the given logger.
+func NewLoggerV2(logger *logging.Logger, opts ...LoggerV2Option) *LoggerV2 {
+	l := &LoggerV2{
+		logger: logger,
+	}
+	if v, err := strconv.Atoi(os.Getenv("GRPC_GO_LOG_VERBOSITY_LEVEL")); err == nil {
+		l.verbosity = v
+	}
+	for _, fn := range opts {
+		fn(l)
+	}
+	// Most messages are written without component and depth.
+	l.componentLogger("", 0)
+	return l
+}
+
+// ReplaceGrpcLogger sets a LoggerV2 writing to a child of the given logger
+// named "grpc" as the logger of gRPC. It must be called before any gRPC
+// functions.
+func ReplaceGrpcLogger(logger *logging.Logger, opts ...LoggerV2Option) {
+	grpclogv2.SetLoggerV2(NewLoggerV2(logger.Named("grpc"), opts...))
+}
+
+// Info logs to the info level.
+func (l *LoggerV2) Info(args ...interface{}) {
+	l.log(0, zapcore.InfoLevel, args)
+}
+
+// Infoln logs to the info level.
+func (l *LoggerV2) Infoln(args ...interface{}) {
+	l.log(0, zapcore.InfoLevel, sprintln(args))
+}
+
+// Infof logs to the info level.
+func (l *LoggerV2) Infof(format string, args ...interface{}) {
+	l.log(0, zapcore.InfoLevel, []interface{}{fmt.Sprintf(format, args...)})
+}
+
+// InfoDepth logs to the info level at the given call depth.
+func (l *LoggerV2) InfoDepth(depth int, args ...interface{}) {
+	l.log(depth, zapcore.InfoLevel, args)
+}
+
+// Warning logs to the warn level.
+func (l *LoggerV2) Warning(args ...interface{}) {
+	l.log(0, zapcore.WarnLevel, args)
+}
+
+// Warningln logs to the warn level.
+func (l *LoggerV2) Warningln(args ...interface{}) {
+	l.log(0, zapcore.WarnLevel, sprintln(args))
+}
+
+// Warningf logs to the warn level.
+func (l *LoggerV2) Warningf(format string, args ...interface{}) {
+	l.log(0, zapcore.WarnLevel, []interface{}{fmt.Sprintf(format, args...)})
+}
+
+// WarningDepth logs to the warn level at the given call depth.
+func (l *LoggerV2) WarningDepth(depth int, args ...interface{}) {
+	l.log(depth, zapcore.WarnLevel, args)
+}
+
+// Error logs to the error level.
+func (l *LoggerV2) Error(args ...interface{}) {
+	l.log(0, zapcore.ErrorLevel, args)
+}
+
+// Errorln logs to the error level.
+func (l *LoggerV2) Errorln(args ...interface{}) {
+	l.log(0, zapcore.ErrorLevel, sprintln(args))
+}
+
+// Errorf logs to the error level.
+func (l *LoggerV2) Errorf(format string, args ...interface{}) {
+	l.log(0, zapcore.ErrorLevel, []interface{}{fmt.Sprintf(format, args...)})
+}
+
+// ErrorDepth logs to the error level at the given call depth.
+func (l *LoggerV2) ErrorDepth(depth int, args ...interface{}) {
+	l.log(depth, zapcore.ErrorLevel, args)
+}
+
+// Fatal logs to the fatal level and then calls os.Exit(1).
+func (l *LoggerV2) Fatal(args ...interface{}) {
+	l.log(0, zapcore.FatalLevel, args)
+}
+
+// Fatalln logs to the fatal level and then calls os.Exit(1).
+func (l *LoggerV2) Fatalln(args ...interface{}) {
+	l.log(0, zapcore.FatalLevel, sprintln(args))
+}
+
+// Fatalf logs to the fatal level and then calls os.Exit(1).
+func (l *LoggerV2) Fatalf(format string, args ...interface{}) {
+	l.log(0, zapcore.FatalLevel, []interface{}{fmt.Sprintf(format, args...)})
+}
+
+// FatalDepth logs to the fatal level at the given call depth and then calls
+// os.Exit(1).
+func (l *LoggerV2) FatalDepth(depth int, args ...interface{}) {
+	l.log(depth, zapcore.FatalLevel, args)
+}
+
+// V reports whether the verbosity level l is at least the requested one.
+func (l *LoggerV2) V(level int) bool {
+	return level <= l.verbosity
+}
+
+// log writes the message to the logger of its component. The depth is the
+// number of callers to skip above the public method.
+func (l *LoggerV2) log(depth int, level zapcore.Level, args []interface{}) {
+	component, msg := splitComponent(args)
+	logger := l.componentLogger(component, depth)
+	if ce := logger.Check(level, msg); ce != nil {
+		ce.Write()
+	}
+}
+
+// componentLogger returns the logger for the given component and call depth.
+// The loggers are created once and reused by the following messages.
+func (l *LoggerV2) componentLogger(component string, depth int) *logging.Logger {
+	key := componentKey{component: component, depth: depth}
+	if v, ok := l.components.Load(key); ok {
+		return v.(*logging.Logger)
+	}
+	logger := l.logger
+	if component != "" {
+		logger = logger.Named(component)
+	}
+	// Skip the log method and the public one.
+	v, _ := l.components.LoadOrStore(key, logger.Clone(zap.AddCallerSkip(depth+1)))
+	return v.(*logging.Logger)
+}
+
+// sprintln formats the arguments like fmt.Sprintln without the new line.
+func sprintln(args []interface{}) []interface{} {
+	return []interface{}{strings.TrimSuffix(fmt.Sprintln(args...), "\n")}
+}
+
+// splitComponent returns the gRPC component of a message and the message
+// without it. gRPC tags the messages of a component adding "[component]" as
+// the first argument.
+func splitComponent(args []interface{}) (component, msg string) {
+	if len(args) > 0 {
+		if s, ok := args[0].(string); ok && len(s) > 2 && s[0] == '[' && s[len(s)-1] == ']' && !strings.Contains(s, " ") {
+			return s[1 : len(s)-1], strings.TrimSpace(fmt.Sprint(args[1:]...))
+		}
+	}
+	msg = fmt.Sprint(args...)
+	if strings.HasPrefix(msg, "[") {
+		if i := strings.Index(msg, "] "); i > 1 && !strings.Contains(msg[1:i], " ") {
+			return msg[1:i], msg[i+2:]
+		}
+	}
+	return "", msg
+}
@@ -0,0 +1,93 @@
+package grpclog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/smallstep/logging"
+	"github.com/smallstep/logging/logtest"
+)
+
+func TestLoggerV2(t *testing.T) {
+	logger, rec := logtest.New("test")
+	l := NewLoggerV2(logger.Named("grpc"), WithVerbosity(2))
+
+	l.InfoDepth(0, "[transport]", "transport: closing connection")
+	l.Warningf("[core] %s", "channel switches to TRANSIENT_FAILURE")
+	l.Errorln("plain", "error")
+	if !l.V(2) || l.V(3) {
+		t.Errorf("LoggerV2.V() does not use the verbosity")
+	}
+
+	all := rec.All()
+	logtest.AssertCount(t, all, 3)
+	logtest.AssertCount(t, all.FilterName("test.grpc.transport").FilterMessage("transport: closing connection").FilterLevel(logging.InfoLevel), 1)
+	logtest.AssertCount(t, all.FilterName("test.grpc.core").FilterMessage("channel switches to TRANSIENT_FAILURE").FilterLevel(logging.WarnLevel), 1)
+	logtest.AssertCount(t, all.FilterName("test.grpc").FilterMessage("plain error").FilterLevel(logging.ErrorLevel), 1)
+}
+
+func TestLoggerV2_componentLoggers(t *testing.T) {
+	logger, rec := logtest.New("test", logging.WithCaller(&logging.Caller{}))
+	l := NewLoggerV2(logger)
+
+	l.Info("[core]", "first")
+	l.Info("[core]", "second")
+	func() { l.InfoDepth(1, "[core]", "depth") }()
+	first := l.componentLogger("core", 0)
+	if second := l.componentLogger("core", 0); first != second {
+		t.Error("LoggerV2.componentLogger() does not reuse the loggers")
+	}
+
+	for _, e := range rec.All() {
+		if e.Caller.File == "" || !strings.HasSuffix(e.Caller.File, "_test.go") {
+			t.Errorf("entry %q caller = %v, want the test", e.Message, e.Caller)
+		}
+	}
+}
+
+func TestLoggerV2_V(t *testing.T) {
+	logger, _ := logtest.New("test")
+
+	t.Setenv("GRPC_GO_LOG_VERBOSITY_LEVEL", "1")
+	l := NewLoggerV2(logger)
+	if !l.V(0) || !l.V(1) || l.V(2) {
+		t.Errorf("LoggerV2.V() does not use GRPC_GO_LOG_VERBOSITY_LEVEL")
+	}
+	// The option takes precedence over the environment.
+	l = NewLoggerV2(logger, WithVerbosity(0))
+	if !l.V(0) || l.V(1) {
+		t.Errorf("LoggerV2.V() does not use the verbosity option")
+	}
+
+	t.Setenv("GRPC_GO_LOG_VERBOSITY_LEVEL", "invalid")
+	if l := NewLoggerV2(logger); !l.V(0) || l.V(1) {
+		t.Errorf("LoggerV2.V() does not default to 0")
+	}
+}
+
+func TestSplitComponent(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []interface{}
+		wantComponent string
+		wantMsg       string
+	}{
+		{"tag argument", []interface{}{"[transport]", "transport: closing connection"}, "transport", "transport: closing connection"},
+		{"tag argument with values", []interface{}{"[core]", "channel ", 1, " ready"}, "core", "channel 1 ready"},
+		{"tag prefix", []interface{}{"[core] channel switches to READY"}, "core", "channel switches to READY"},
+		{"tag only", []interface{}{"[core]"}, "core", ""},
+		{"no tag", []interface{}{"plain message"}, "", "plain message"},
+		{"brackets with spaces", []interface{}{"[not a tag] message"}, "", "[not a tag] message"},
+		{"empty brackets", []interface{}{"[] message"}, "", "[] message"},
+		{"non string argument", []interface{}{42, "message"}, "", "42message"},
+		{"no arguments", nil, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			component, msg := splitComponent(tt.args)
+			if component != tt.wantComponent || msg != tt.wantMsg {
+				t.Errorf("splitComponent() = %q, %q, want %q, %q", component, msg, tt.wantComponent, tt.wantMsg)
+			}
+		})
+	}
+}
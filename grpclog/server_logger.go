@@ -26,9 +26,11 @@ type serverLogger struct {
 	logRequests     bool
 	logResponses    bool
 	timeFormat      string
+	recovery        RecoveryFunc
 }
 
-func newServerLogger(logger *logging.Logger, typ interceptorType) *serverLogger {
+func newServerLogger(logger *logging.Logger, typ interceptorType, opts []Option) *serverLogger {
+	o := (&options{recovery: DefaultRecovery}).apply(opts)
 	return &serverLogger{
 		Logger:          logger,
 		interceptorType: typ,
@@ -36,22 +38,60 @@ func newServerLogger(logger *logging.Logger, typ interceptorType) *serverLogger
 		logRequests:     logger.LogRequests(),
 		logResponses:    logger.LogResponses(),
 		timeFormat:      logger.TimeFormat(),
+		recovery:        o.recovery,
 	}
 }
 
-func (l *serverLogger) Log(ctx context.Context, fullMethod string, t time.Time, duration time.Duration, extra []zap.Field, grpcErr error) {
-	var pkg string
-	code := status.Code(grpcErr)
-	service := path.Dir(fullMethod)[1:]
-	method := path.Base(fullMethod)
+// recover recovers from a panic in a handler, it logs the panic and sets the
+// error returned by the recovery function. It must be deferred.
+func (l *serverLogger) recover(ctx context.Context, fullMethod string, err *error) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	l.LogPanic(ctx, fullMethod, v)
+	if l.recovery != nil {
+		*err = l.recovery(ctx, v)
+	}
+	if *err == nil {
+		*err = DefaultRecovery(ctx, v)
+	}
+}
+
+// LogPanic writes the value of a panic and the stack trace.
+func (l *serverLogger) LogPanic(ctx context.Context, fullMethod string, v interface{}) {
+	pkg, service, method := splitMethod(fullMethod)
+	name, requestID, tracingID := l.correlation(ctx)
+	// The entry does not have the system field so it is not written as an
+	// access log entry, and the stack trace starts where the panic was raised.
+	l.Clone(zap.AddStacktrace(zapcore.ErrorLevel), zap.AddCallerSkip(2)).Error("panic recovered",
+		zap.String("name", name),
+		zap.String("span.kind", "server"),
+		zap.String("grpc.package", pkg),
+		zap.String("grpc.service", service),
+		zap.String("grpc.method", method),
+		zap.String("request-id", requestID),
+		zap.String("tracing-id", tracingID),
+		zap.Any("panic", v),
+	)
+}
+
+// splitMethod returns the package, service and method of the given full
+// method name.
+func splitMethod(fullMethod string) (pkg, service, method string) {
+	service = path.Dir(fullMethod)[1:]
+	method = path.Base(fullMethod)
 	parts := strings.Split(service, ".")
 	if l := len(parts); l > 1 {
 		pkg = strings.Join(parts[:l-1], ".")
 		service = parts[l-1]
 	}
+	return
+}
 
-	var name, requestID, tracingID string
-
+// correlation returns the logger name, the request id and the tracing id of
+// the call.
+func (l *serverLogger) correlation(ctx context.Context) (name, requestID, tracingID string) {
 	// Use (reflected) request ID for logging. It _could_ be empty if it wasn't set
 	// by some (external) middleware, but we stil log the legacy request ID too, so
 	// it shouldn't be too big of an issue.
@@ -68,6 +108,13 @@ func (l *serverLogger) Log(ctx context.Context, fullMethod string, t time.Time,
 			requestID = tp.TraceID()
 		}
 	}
+	return
+}
+
+func (l *serverLogger) Log(ctx context.Context, fullMethod string, t time.Time, duration time.Duration, extra []zap.Field, grpcErr error) {
+	code := status.Code(grpcErr)
+	pkg, service, method := splitMethod(fullMethod)
+	name, requestID, tracingID := l.correlation(ctx)
 
 	fields := []zap.Field{
 		zap.String("name", name),
@@ -118,33 +165,8 @@ func (l *serverLogger) Log(ctx context.Context, fullMethod string, t time.Time,
 }
 
 func (l *serverLogger) LogStream(ctx context.Context, fullMethod, msg string, extra []zap.Field) {
-	var pkg string
-	service := path.Dir(fullMethod)[1:]
-	method := path.Base(fullMethod)
-	parts := strings.Split(service, ".")
-	if l := len(parts); l > 1 {
-		pkg = strings.Join(parts[:l-1], ".")
-		service = parts[l-1]
-	}
-
-	var name, requestID, tracingID string
-
-	// Use (reflected) request ID for logging. It _could_ be empty if it wasn't set
-	// by some (external) middleware, but we stil log the legacy request ID too, so
-	// it shouldn't be too big of an issue.
-	requestID = requestid.FromContext(ctx)
-
-	if s, ok := logging.GetName(ctx); ok {
-		name = s
-	} else {
-		name = l.name
-	}
-	if tp, ok := logging.GetTraceparent(ctx); ok {
-		tracingID = tp.String()
-		if requestID == "" {
-			requestID = tp.TraceID()
-		}
-	}
+	pkg, service, method := splitMethod(fullMethod)
+	name, requestID, tracingID := l.correlation(ctx)
 
 	fields := []zap.Field{
 		zap.String("name", name),
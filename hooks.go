@@ -0,0 +1,193 @@
+package logging
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/smallstep/logging/encoder"
+	"go.uber.org/zap/zapcore"
+)
+
+// hooksQueueSize is the number of entries queued for the hooks, entries are
+// dropped if the queue is full.
+const hooksQueueSize = 4096
+
+// HookEntry contains the information about an entry written to a sink.
+type HookEntry struct {
+	// Level is the level of the entry.
+	Level Level
+	// Name is the name of the logger.
+	Name string
+	// Sink is the name of the sink.
+	Sink string
+	// Size is the number of bytes of the encoded entry.
+	Size int
+	// Time is the time of the entry.
+	Time time.Time
+}
+
+// Hook is a function called after an entry is encoded and written to a sink.
+// An entry written to multiple sinks calls the hook once for each sink.
+//
+// Hooks run in a background goroutine so they cannot block the logger; if
+// they fall behind, entries are dropped and counted in HooksDropped. Hooks
+// are not called for sinks using a custom core.
+type Hook func(HookEntry)
+
+// WithHook adds a hook that will be called after each entry is written.
+func WithHook(hook Hook) Option {
+	return func(o *options) error {
+		o.hooks = append(o.hooks, hook)
+		return nil
+	}
+}
+
+// hookRunner runs the hooks of a logger in a background goroutine.
+type hookRunner struct {
+	hooks    []Hook
+	queue    chan HookEntry
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	stopOnce sync.Once
+	dropped  atomic.Uint64
+}
+
+func newHookRunner(hooks []Hook) *hookRunner {
+	r := &hookRunner{
+		hooks:  hooks,
+		queue:  make(chan HookEntry, hooksQueueSize),
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// send queues the given entry without blocking.
+func (r *hookRunner) send(e HookEntry) {
+	select {
+	case r.queue <- e:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+func (r *hookRunner) run() {
+	defer close(r.doneCh)
+	for {
+		select {
+		case e := <-r.queue:
+			r.call(e)
+		case <-r.stopCh:
+			for {
+				select {
+				case e := <-r.queue:
+					r.call(e)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// call runs the hooks, a hook that panics does not stop the others.
+func (r *hookRunner) call(e HookEntry) {
+	for _, fn := range r.hooks {
+		func() {
+			defer func() { _ = recover() }()
+			fn(e)
+		}()
+	}
+}
+
+// Close runs the hooks for the queued entries and stops the background
+// goroutine.
+func (r *hookRunner) Close() error {
+	r.stopOnce.Do(func() {
+		close(r.stopCh)
+	})
+	<-r.doneCh
+	return nil
+}
+
+// HooksDropped returns the number of entries not sent to the hooks because
+// they were falling behind.
+func (l *Logger) HooksDropped() uint64 {
+	if l.hooks == nil {
+		return 0
+	}
+	return l.hooks.dropped.Load()
+}
+
+// sinkCore is a zapcore.Core that encodes the entries and writes them to the
+// output of a sink, sending the size of each entry to the hooks.
+type sinkCore struct {
+	zapcore.LevelEnabler
+	enc        zapcore.Encoder
+	out        zapcore.WriteSyncer
+	name       string
+	sink       string
+	hooks      *hookRunner
+	errorDepth int
+}
+
+// With adds structured context to the core.
+func (c *sinkCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.enc = c.enc.Clone()
+	fields = encoder.ErrorFields(fields, c.errorDepth)
+	for i := range fields {
+		fields[i].AddTo(clone.enc)
+	}
+	return &clone
+}
+
+// Check determines whether the supplied Entry should be logged.
+func (c *sinkCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write encodes the entry and writes it to the output. Errors are rendered
+// with their causes and stack traces.
+func (c *sinkCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(ent, encoder.ErrorFields(fields, c.errorDepth))
+	if err != nil {
+		return err
+	}
+	size := buf.Len()
+	_, err = c.out.Write(buf.Bytes())
+	buf.Free()
+	if err != nil {
+		return err
+	}
+
+	if c.hooks != nil {
+		name := ent.LoggerName
+		if name == "" {
+			name = c.name
+		}
+		c.hooks.send(HookEntry{
+			Level: Level(ent.Level),
+			Name:  name,
+			Sink:  c.sink,
+			Size:  size,
+			Time:  ent.Time,
+		})
+	}
+
+	if ent.Level > zapcore.ErrorLevel {
+		// Sync on panics and fatal entries, the process might exit.
+		return c.Sync()
+	}
+	return nil
+}
+
+// Sync flushes the output.
+func (c *sinkCore) Sync() error {
+	return c.out.Sync()
+}
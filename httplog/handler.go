@@ -1,6 +1,7 @@
 package httplog
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -9,10 +10,12 @@ import (
 	"github.com/smallstep/logging"
 	"github.com/smallstep/logging/requestid"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 type options struct {
 	Redactors []RedactorFunc
+	Recovery  RecoveryFunc
 }
 
 // Option is the type used to modify logger options.
@@ -36,6 +39,27 @@ func WithRedactor(fn RedactorFunc) Option {
 	}
 }
 
+// RecoveryFunc is a function that writes the response of a request whose
+// handler panicked. The value is the one passed to panic.
+type RecoveryFunc func(w http.ResponseWriter, r *http.Request, v interface{})
+
+// WithRecovery is an option that sets the function used to write the response
+// after a panic. Defaults to DefaultRecovery.
+func WithRecovery(fn RecoveryFunc) Option {
+	return func(o *options) {
+		o.Recovery = fn
+	}
+}
+
+// DefaultRecovery writes a 500 Internal Server Error response if the handler
+// has not written anything.
+func DefaultRecovery(w http.ResponseWriter, _ *http.Request, _ interface{}) {
+	if rw, ok := w.(ResponseLogger); ok && (rw.Size() > 0 || rw.StatusCode() != http.StatusOK) {
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
 // LoggerHandler creates a logger handler
 type LoggerHandler struct {
 	*logging.Logger
@@ -49,7 +73,7 @@ type LoggerHandler struct {
 
 // Middleware returns the given http.Handler with the logger integrated.
 func Middleware(logger *logging.Logger, next http.Handler, opts ...Option) http.Handler {
-	o := new(options).apply(opts)
+	o := (&options{Recovery: DefaultRecovery}).apply(opts)
 	h := logging.Tracing(logger.TraceHeader())
 	return h(&LoggerHandler{
 		Logger:       logger,
@@ -64,10 +88,11 @@ func Middleware(logger *logging.Logger, next http.Handler, opts ...Option) http.
 
 // ServeHTTP implements the http.Handler and call to the handler to log with a
 // custom http.ResponseWriter that records the response code and the number of
-// bytes sent.
+// bytes sent. Panics in the handler are logged and recovered, and the request
+// is still logged.
 func (l *LoggerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	var rw ResponseLogger
-	t := time.Now()
+	t := l.Now()
 	if l.logResponses {
 		rw = NewRawResponseLogger(w)
 	} else {
@@ -79,20 +104,63 @@ func (l *LoggerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	// Serve next http handler.
-	l.next.ServeHTTP(rw, r)
-	d := time.Since(t)
+	aborted := l.serve(rw, r)
+	d := l.Now().Sub(t)
 	// Redact request and response if configured.
 	for _, redactor := range l.options.Redactors {
 		redactor(rw, r)
 	}
 	// Write logs.
 	l.writeEntry(rw, r, t, d)
+	// Let the server abort the response.
+	if aborted {
+		panic(http.ErrAbortHandler)
+	}
 }
 
-// writeEntry writes to the Logger writer the request information in the logger.
-func (l *LoggerHandler) writeEntry(w ResponseLogger, r *http.Request, t time.Time, d time.Duration) {
+// serve calls the next handler recovering from panics. It returns true if the
+// handler panicked with http.ErrAbortHandler.
+func (l *LoggerHandler) serve(w ResponseLogger, r *http.Request) (aborted bool) {
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+		if err, ok := v.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+			aborted = true
+			return
+		}
+		l.writePanic(r, v)
+		if _, ok := w.Field(ErrorKey); !ok {
+			w.WithField(ErrorKey, fmt.Errorf("panic: %v", v))
+		}
+		if l.options.Recovery != nil {
+			l.options.Recovery(w, r, v)
+		}
+	}()
+	l.next.ServeHTTP(w, r)
+	return false
+}
+
+// writePanic writes the value of a panic and the stack trace.
+func (l *LoggerHandler) writePanic(r *http.Request, v interface{}) {
+	name, requestID, tracingID := l.correlation(r)
+	// The entry does not have the system field so it is not written as an
+	// access log entry, and the stack trace starts where the panic was raised.
+	l.Clone(zap.AddStacktrace(zapcore.ErrorLevel), zap.AddCallerSkip(2)).Error("panic recovered",
+		zap.String("name", name),
+		zap.String("request-id", requestID),
+		zap.String("tracing-id", tracingID),
+		zap.String("method", r.Method),
+		zap.String("path", r.URL.RequestURI()),
+		zap.Any("panic", v),
+	)
+}
+
+// correlation returns the logger name, the request id and the tracing id of
+// the request.
+func (l *LoggerHandler) correlation(r *http.Request) (name, requestID, tracingID string) {
 	ctx := r.Context()
-	var name, requestID, tracingID string
 
 	// Use (reflected) request ID for logging. It _could_ be empty if it wasn't set
 	// by some (external) middleware, but we stil log the legacy request ID too, so
@@ -110,6 +178,12 @@ func (l *LoggerHandler) writeEntry(w ResponseLogger, r *http.Request, t time.Tim
 			requestID = tp.TraceID()
 		}
 	}
+	return
+}
+
+// writeEntry writes to the Logger writer the request information in the logger.
+func (l *LoggerHandler) writeEntry(w ResponseLogger, r *http.Request, t time.Time, d time.Duration) {
+	name, requestID, tracingID := l.correlation(r)
 
 	// Remote hostname
 	addr, _, err := net.SplitHostPort(r.RemoteAddr)
@@ -0,0 +1,78 @@
+package httplog_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/smallstep/logging"
+	"github.com/smallstep/logging/httplog"
+	"github.com/smallstep/logging/logtest"
+)
+
+func TestHTTPRecovery(t *testing.T) {
+	logger, rec := logtest.New("test")
+
+	h := httplog.Middleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/foo", http.NoBody))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+
+	panics := rec.All().FilterMessage("panic recovered")
+	if logtest.AssertCount(t, panics, 1) {
+		if e := panics[0]; e.Level != logging.ErrorLevel || e.String("panic") != "boom" ||
+			e.String("request-id") == "" || e.Stack == "" || e.String("system") != "" {
+			t.Errorf("unexpected entry %v", e)
+		}
+	}
+	entries := rec.All().HTTP().FilterField("status", http.StatusInternalServerError)
+	if logtest.AssertCount(t, entries, 1) {
+		logtest.AssertHTTP(t, entries[0], logtest.HTTPEntry{
+			Name:      "test",
+			Method:    "GET",
+			Path:      "/foo",
+			RequestID: panics[0].String("request-id"),
+		})
+	}
+
+	// Custom responses
+	h = httplog.Middleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}), httplog.WithRecovery(func(w http.ResponseWriter, r *http.Request, v interface{}) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/foo", http.NoBody))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestHTTPRecovery_common(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := logging.New("test", logging.WithSink("out", &out, logging.AllLevels(), "common"))
+	if err != nil {
+		t.Fatalf("logging.New() error = %v", err)
+	}
+
+	h := httplog.Middleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/foo", http.NoBody))
+
+	s := out.String()
+	for _, want := range []string{"panic recovered\n", ` "GET /foo -" `, ` panic="boom"`, "\n  stacktrace:\n", "httplog_test.TestHTTPRecovery_common"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("output %q does not contain %q", s, want)
+		}
+	}
+	if strings.Contains(s, "writePanic") {
+		t.Errorf("output %q contains the frames of the middleware", s)
+	}
+}
@@ -0,0 +1,46 @@
+// Package testclock provides the zapcore.Clock used in the tests of the
+// logging packages.
+package testclock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a zapcore.Clock that only advances when it's told to. It's safe
+// for concurrent use.
+type Clock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+// New returns a clock set to the given time.
+func New(t time.Time) *Clock {
+	return &Clock{t: t}
+}
+
+// Now returns the current time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+// NewTicker returns a time.Ticker using the system clock.
+func (c *Clock) NewTicker(d time.Duration) *time.Ticker {
+	return time.NewTicker(d)
+}
+
+// Add advances the clock by the given duration.
+func (c *Clock) Add(d time.Duration) {
+	c.mu.Lock()
+	c.t = c.t.Add(d)
+	c.mu.Unlock()
+}
+
+// Set sets the time of the clock.
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	c.t = t
+	c.mu.Unlock()
+}
@@ -0,0 +1,40 @@
+package logging
+
+import (
+	"github.com/smallstep/logging/encoder"
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultJournaldSocket is the path of the socket used by systemd-journald
+// for the native protocol.
+const DefaultJournaldSocket = "/run/systemd/journal/socket"
+
+// Journald configures a sink with the "journald" output. The entries are sent
+// using the native journal protocol, with the fields of the entries as
+// journal fields.
+type Journald struct {
+	// Socket is the path of the journal socket. Defaults to
+	// /run/systemd/journal/socket.
+	Socket string `json:"socket"`
+	// Identifier is the SYSLOG_IDENTIFIER of the entries without a logger
+	// name. Defaults to the name of the logger.
+	Identifier string `json:"identifier"`
+}
+
+func (j *Journald) socket() string {
+	if j == nil || j.Socket == "" {
+		return DefaultJournaldSocket
+	}
+	return j.Socket
+}
+
+// newJournaldEncoder returns the encoder used by a sink with the journald
+// format.
+func newJournaldEncoder(j *Journald, name string, config zapcore.EncoderConfig) zapcore.Encoder {
+	if j != nil && j.Identifier != "" {
+		name = j.Identifier
+	}
+	return encoder.NewJournaldEncoder(config, encoder.JournaldConfig{
+		Identifier: name,
+	})
+}
@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"net"
+	"os"
+	"sync"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// JournaldWriter is a zapcore.WriteSyncer that sends each write as an entry to
+// the journal socket. Entries too large for a datagram are written to a
+// sealed memfd and its file descriptor is sent instead.
+type JournaldWriter struct {
+	mu   sync.Mutex
+	addr *net.UnixAddr
+	conn *net.UnixConn
+}
+
+// NewJournaldWriter creates a JournaldWriter that sends the entries to the
+// socket in the given path.
+func NewJournaldWriter(socket string) (*JournaldWriter, error) {
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening journald socket")
+	}
+	return &JournaldWriter{
+		addr: &net.UnixAddr{Name: socket, Net: "unixgram"},
+		conn: conn,
+	}, nil
+}
+
+// Write sends p as a journal entry.
+func (w *JournaldWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, _, err := w.conn.WriteMsgUnix(p, nil, w.addr)
+	if err == nil {
+		return len(p), nil
+	}
+	if !errors.Is(err, syscall.EMSGSIZE) && !errors.Is(err, syscall.ENOBUFS) {
+		return 0, errors.Wrapf(err, "error writing to %s", w.addr.Name)
+	}
+	if err := w.writeMemfd(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// writeMemfd writes p to a sealed memfd and sends its file descriptor.
+func (w *JournaldWriter) writeMemfd(p []byte) error {
+	fd, err := unix.MemfdCreate("logging-journal", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return errors.Wrap(err, "error creating memfd")
+	}
+	f := os.NewFile(uintptr(fd), "logging-journal")
+	defer f.Close()
+
+	if _, err := f.Write(p); err != nil {
+		return errors.Wrap(err, "error writing memfd")
+	}
+	if _, err := unix.FcntlInt(f.Fd(), unix.F_ADD_SEALS, unix.F_SEAL_SHRINK|unix.F_SEAL_GROW|unix.F_SEAL_WRITE|unix.F_SEAL_SEAL); err != nil {
+		return errors.Wrap(err, "error sealing memfd")
+	}
+	if _, _, err := w.conn.WriteMsgUnix(nil, unix.UnixRights(int(f.Fd())), w.addr); err != nil {
+		return errors.Wrapf(err, "error writing to %s", w.addr.Name)
+	}
+	return nil
+}
+
+// Sync implements zapcore.WriteSyncer, entries are not buffered.
+func (w *JournaldWriter) Sync() error {
+	return nil
+}
+
+// Close closes the socket.
+func (w *JournaldWriter) Close() error {
+	return w.conn.Close()
+}
@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// readJournal reads an entry from the fake journal socket, following the file
+// descriptor if the entry has been sent using a memfd.
+func readJournal(t *testing.T, conn *net.UnixConn) []byte {
+	t.Helper()
+	b := make([]byte, 64*1024)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, oobn, _, _, err := conn.ReadMsgUnix(b, oob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if oobn == 0 {
+		return b[:n]
+	}
+
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("unexpected control message: %v", err)
+	}
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil || len(fds) != 1 {
+		t.Fatalf("unexpected unix rights: %v", err)
+	}
+	f := os.NewFile(uintptr(fds[0]), "memfd")
+	defer f.Close()
+	// The offset is shared with the writer.
+	data, err := io.ReadAll(io.NewSectionReader(f, 0, 1<<30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// parseJournal parses an entry in the native journal protocol.
+func parseJournal(t *testing.T, b []byte) map[string]string {
+	t.Helper()
+	fields := make(map[string]string)
+	for len(b) > 0 {
+		i := strings.IndexAny(string(b), "=\n")
+		if i < 0 {
+			t.Fatalf("invalid journal entry %q", b)
+		}
+		name := string(b[:i])
+		if b[i] == '=' {
+			end := strings.IndexByte(string(b[i+1:]), '\n')
+			fields[name] = string(b[i+1 : i+1+end])
+			b = b[i+1+end+1:]
+			continue
+		}
+		size := int(binary.LittleEndian.Uint64(b[i+1 : i+9]))
+		fields[name] = string(b[i+9 : i+9+size])
+		b = b[i+9+size+1:]
+	}
+	return fields
+}
+
+func TestJournald(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	logger, err := New("test", WithConfig([]byte(fmt.Sprintf(`{"sinks":[{"output":"journald","journald":{"socket":%q}}]}`, socket))))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer logger.Close()
+
+	logger.Warn("warn message", zap.String("request-id", "abc"), zap.Int("status", 200), zap.String("message", "multi\nline"))
+	got := parseJournal(t, readJournal(t, conn))
+	want := map[string]string{
+		"MESSAGE":           "warn message",
+		"PRIORITY":          "4",
+		"SYSLOG_IDENTIFIER": "test",
+		"REQUEST_ID":        "abc",
+		"STATUS":            "200",
+		"FIELD_MESSAGE":     "multi\nline",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("field %s = %q, want %q", k, got[k], v)
+		}
+	}
+
+	// Large entries are sent using a memfd.
+	large := strings.Repeat("x", 1024*1024)
+	logger.Named("child").Info(large)
+	got = parseJournal(t, readJournal(t, conn))
+	if got["MESSAGE"] != large || got["PRIORITY"] != "6" || got["SYSLOG_IDENTIFIER"] != "test.child" {
+		t.Errorf("unexpected large entry: PRIORITY=%s SYSLOG_IDENTIFIER=%s len(MESSAGE)=%d",
+			got["PRIORITY"], got["SYSLOG_IDENTIFIER"], len(got["MESSAGE"]))
+	}
+}
@@ -0,0 +1,31 @@
+//go:build !linux
+
+package logging
+
+import (
+	"github.com/pkg/errors"
+)
+
+// JournaldWriter is a zapcore.WriteSyncer that sends each write as an entry to
+// the journal socket. It is only supported on Linux.
+type JournaldWriter struct{}
+
+// NewJournaldWriter returns an error, journald is only supported on Linux.
+func NewJournaldWriter(socket string) (*JournaldWriter, error) {
+	return nil, errors.New("journald is only supported on linux")
+}
+
+// Write implements io.Writer.
+func (w *JournaldWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("journald is only supported on linux")
+}
+
+// Sync implements zapcore.WriteSyncer.
+func (w *JournaldWriter) Sync() error {
+	return nil
+}
+
+// Close implements io.Closer.
+func (w *JournaldWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,212 @@
+package logging
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// levelSet keeps the level of a logger, the levels overridden for some logger
+// names and the sampling policy. It is shared by all the loggers created from
+// the same root logger. They are stored together so they can be changed
+// atomically.
+type levelSet struct {
+	mu    sync.Mutex
+	state atomic.Pointer[levelState]
+}
+
+type levelState struct {
+	level    Level
+	levels   map[string]Level
+	patterns []levelPattern
+	sampling *Sampling
+}
+
+// levelPattern matches a logger name and all its descendants, the pattern
+// "authority" or "authority.*" matches "authority" and
+// "authority.provisioner".
+type levelPattern struct {
+	prefix string
+	level  Level
+}
+
+func (p levelPattern) match(name string) bool {
+	switch {
+	case p.prefix == "":
+		return true
+	case len(name) == len(p.prefix):
+		return name == p.prefix
+	default:
+		return strings.HasPrefix(name, p.prefix) && name[len(p.prefix)] == '.'
+	}
+}
+
+// levelPrefix returns the prefix of the names matched by the given name of a
+// level override.
+func levelPrefix(name string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(name, "*"), ".")
+}
+
+// validateLevels validates the level overrides. The names cannot be empty,
+// two names cannot match the same loggers, like "authority" and
+// "authority.*", as it would not be clear which level applies, and the levels
+// must be known.
+func validateLevels(v *validator, path string, levels map[string]Level) {
+	names := make([]string, 0, len(levels))
+	for name := range levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	prefixes := make(map[string]string, len(names))
+	for _, name := range names {
+		if name == "" {
+			v.add(path, "logger name cannot be empty")
+			continue
+		}
+		prefix := levelPrefix(name)
+		if other, ok := prefixes[prefix]; ok {
+			v.add(path, "logger names '%s' and '%s' match the same loggers", other, name)
+		}
+		prefixes[prefix] = name
+		if l := levels[name]; !l.valid() {
+			v.add(path+"."+name, "unknown level %s", l)
+		}
+	}
+}
+
+func newLevelState(level Level, levels map[string]Level, sampling *Sampling) *levelState {
+	st := &levelState{
+		level:    level,
+		levels:   make(map[string]Level, len(levels)),
+		patterns: make([]levelPattern, 0, len(levels)),
+		sampling: sampling,
+	}
+	for k, v := range levels {
+		st.levels[k] = v
+		st.patterns = append(st.patterns, levelPattern{prefix: levelPrefix(k), level: v})
+	}
+	// Longest prefix first.
+	sort.Slice(st.patterns, func(i, j int) bool {
+		return len(st.patterns[i].prefix) > len(st.patterns[j].prefix)
+	})
+	return st
+}
+
+func newLevelSet(level Level, levels map[string]Level, sampling *Sampling) *levelSet {
+	s := new(levelSet)
+	s.state.Store(newLevelState(level, levels, sampling))
+	return s
+}
+
+// getLevel returns the level of the logger.
+func (s *levelSet) getLevel() Level {
+	return s.state.Load().level
+}
+
+// setLevel replaces the level of the logger.
+func (s *levelSet) setLevel(level Level) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.state.Load()
+	s.state.Store(newLevelState(level, st.levels, st.sampling))
+}
+
+// getOverrides returns a copy of the level overrides.
+func (s *levelSet) getOverrides() map[string]Level {
+	st := s.state.Load()
+	m := make(map[string]Level, len(st.levels))
+	for k, v := range st.levels {
+		m[k] = v
+	}
+	return m
+}
+
+// setOverrides replaces the level overrides.
+func (s *levelSet) setOverrides(levels map[string]Level) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.state.Load()
+	s.state.Store(newLevelState(st.level, levels, st.sampling))
+}
+
+// getSampling returns the sampling policy, nil if sampling is disabled.
+func (s *levelSet) getSampling() *Sampling {
+	return s.state.Load().sampling
+}
+
+// setSampling replaces the sampling policy.
+func (s *levelSet) setSampling(sampling *Sampling) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.state.Load()
+	s.state.Store(newLevelState(st.level, st.levels, sampling))
+}
+
+// set replaces the level, the level overrides and the sampling policy, and
+// returns the previous state.
+func (s *levelSet) set(level Level, levels map[string]Level, sampling *Sampling) *levelState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old := s.state.Load()
+	s.state.Store(newLevelState(level, levels, sampling))
+	return old
+}
+
+// levelFor returns the level for the given logger name, the one of the
+// longest matching override, or the logger level if no override matches.
+func (s *levelSet) levelFor(name string) Level {
+	st := s.state.Load()
+	for _, p := range st.patterns {
+		if p.match(name) {
+			return p.level
+		}
+	}
+	return st.level
+}
+
+// levelCore is a zapcore.Core that filters the entries using the level of a
+// logger name.
+type levelCore struct {
+	zapcore.Core
+	levels *levelSet
+	name   string
+}
+
+func newLevelCore(core zapcore.Core, levels *levelSet, name string) *levelCore {
+	return &levelCore{
+		Core:   core,
+		levels: levels,
+		name:   name,
+	}
+}
+
+// named returns a copy of the core that uses the level of the given name.
+func (c *levelCore) named(name string) *levelCore {
+	return newLevelCore(c.Core, c.levels, name)
+}
+
+// Enabled implements zapcore.LevelEnabler.
+func (c *levelCore) Enabled(lvl zapcore.Level) bool {
+	return Level(lvl) >= c.levels.levelFor(c.name) && c.Core.Enabled(lvl)
+}
+
+// Level implements the optional Level method used by zapcore.LevelOf.
+func (c *levelCore) Level() zapcore.Level {
+	return zapcore.Level(c.levels.levelFor(c.name))
+}
+
+// With adds structured context to the core.
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return newLevelCore(c.Core.With(fields), c.levels, c.name)
+}
+
+// Check determines whether the supplied Entry should be logged.
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if Level(ent.Level) < c.levels.levelFor(c.name) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
@@ -0,0 +1,164 @@
+package logging
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LevelHandler is an http.Handler that shows and changes the level of a
+// logger at runtime.
+//
+// A GET request returns the current level:
+//
+//	{"level":"info"}
+//
+// A PUT request changes the level, optionally reverting to the previous one
+// after the given ttl. The level and ttl can be sent in a JSON body or as form
+// values:
+//
+//	{"level":"debug","ttl":"15m"}
+//
+// While a ttl is active, the response includes the time when the level will be
+// reverted.
+type LevelHandler struct {
+	logger  *Logger
+	mu      sync.Mutex
+	timer   *time.Timer
+	restore Level
+	expiry  time.Time
+}
+
+type levelRequest struct {
+	Level *Level   `json:"level"`
+	TTL   Duration `json:"ttl"`
+}
+
+type levelResponse struct {
+	Level    Level      `json:"level"`
+	RevertAt *time.Time `json:"revertAt,omitempty"`
+}
+
+type levelError struct {
+	Error string `json:"error"`
+}
+
+// NewLevelHandler returns a LevelHandler for the given logger.
+func NewLevelHandler(logger *Logger) *LevelHandler {
+	return &LevelHandler{logger: logger}
+}
+
+// LevelHandler returns an http.Handler that shows and changes the level of the
+// logger. See [LevelHandler] for more information.
+func (l *Logger) LevelHandler() http.Handler {
+	return NewLevelHandler(l)
+}
+
+// ServeHTTP implements http.Handler.
+func (h *LevelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.writeLevel(w)
+	case http.MethodPut:
+		req, err := decodeLevelRequest(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, levelError{Error: err.Error()})
+			return
+		}
+		h.setLevel(*req.Level, req.TTL.Duration)
+		h.writeLevel(w)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		writeJSON(w, http.StatusMethodNotAllowed, levelError{
+			Error: "only GET and PUT are supported",
+		})
+	}
+}
+
+// setLevel sets the level of the logger. If ttl is positive, the previous
+// level is restored after it. A new call cancels any pending restore.
+func (h *LevelHandler) setLevel(level Level, ttl time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	previous := h.logger.Level()
+	if h.timer != nil {
+		// Keep the level that the pending timer would restore.
+		h.timer.Stop()
+		previous = h.restore
+		h.timer = nil
+		h.expiry = time.Time{}
+	}
+
+	h.logger.SetLevel(level)
+	if ttl > 0 {
+		var t *time.Timer
+		t = time.AfterFunc(ttl, func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			// Ignore timers cancelled while waiting for the lock.
+			if h.timer == t {
+				h.logger.SetLevel(h.restore)
+				h.timer = nil
+				h.expiry = time.Time{}
+			}
+		})
+		h.timer = t
+		h.restore = previous
+		h.expiry = time.Now().Add(ttl)
+	}
+}
+
+func (h *LevelHandler) writeLevel(w http.ResponseWriter) {
+	h.mu.Lock()
+	resp := levelResponse{Level: h.logger.Level()}
+	if !h.expiry.IsZero() {
+		t := h.expiry
+		resp.RevertAt = &t
+	}
+	h.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func decodeLevelRequest(r *http.Request) (*levelRequest, error) {
+	req := new(levelRequest)
+	switch ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct {
+	case "application/x-www-form-urlencoded":
+		if v := r.FormValue("level"); v != "" {
+			req.Level = new(Level)
+			if err := req.Level.UnmarshalText([]byte(v)); err != nil {
+				return nil, err
+			}
+		}
+		if v := r.FormValue("ttl"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, errors.Errorf("invalid ttl: %q", v)
+			}
+			req.TTL.Duration = d
+		}
+	default:
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			return nil, errors.Wrap(err, "error decoding request")
+		}
+	}
+
+	switch {
+	case req.Level == nil:
+		return nil, errors.New("level is required")
+	case req.TTL.Duration < 0:
+		return nil, errors.New("ttl cannot be negative")
+	}
+	return req, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,75 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLevelHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New("test", WithOutput(&buf), WithErrorOutput(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := logger.LevelHandler()
+
+	do := func(method, contentType, body string) (int, levelResponse) {
+		t.Helper()
+		req := httptest.NewRequest(method, "/level", strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		var resp levelResponse
+		if w.Code == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return w.Code, resp
+	}
+
+	if code, resp := do("GET", "", ""); code != 200 || resp.Level != InfoLevel || resp.RevertAt != nil {
+		t.Errorf("GET = %d %+v", code, resp)
+	}
+
+	logger.Debug("not logged")
+	if code, resp := do("PUT", "application/json", `{"level":"debug"}`); code != 200 || resp.Level != DebugLevel {
+		t.Errorf("PUT = %d %+v", code, resp)
+	}
+	logger.Debug("logged")
+	if s := buf.String(); strings.Contains(s, "not logged") || !strings.Contains(s, "logged") {
+		t.Errorf("unexpected output %q", s)
+	}
+
+	if code, resp := do("PUT", "application/x-www-form-urlencoded", "level=error&ttl=50ms"); code != 200 || resp.Level != ErrorLevel || resp.RevertAt == nil {
+		t.Errorf("PUT = %d %+v", code, resp)
+	}
+	if logger.Level() != ErrorLevel {
+		t.Errorf("Logger.Level() = %v, want %v", logger.Level(), ErrorLevel)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if code, resp := do("GET", "", ""); code != 200 || resp.Level != DebugLevel || resp.RevertAt != nil {
+		t.Errorf("GET = %d %+v", code, resp)
+	}
+
+	for _, tc := range []struct{ method, contentType, body string }{
+		{"PUT", "application/json", `{"ttl":"1m"}`},
+		{"PUT", "application/json", `{"level":"foo"}`},
+		{"PUT", "application/json", `{"level":"info","ttl":"-1m"}`},
+		{"PUT", "application/x-www-form-urlencoded", "level=info&ttl=foo"},
+	} {
+		if code, _ := do(tc.method, tc.contentType, tc.body); code != http.StatusBadRequest {
+			t.Errorf("%s %s = %d, want 400", tc.method, tc.body, code)
+		}
+	}
+	if code, _ := do("POST", "", ""); code != http.StatusMethodNotAllowed {
+		t.Errorf("POST = %d, want 405", code)
+	}
+}
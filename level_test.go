@@ -0,0 +1,122 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLogger_Named(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New("authority", WithOutput(&buf), WithErrorOutput(&buf), WithFormatJSON(),
+		WithConfig([]byte(`{"levels":{"authority.provisioner.*":"debug","authority.db":"error"}}`)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provisioner := logger.Named("provisioner")
+	jwk := provisioner.Named("jwk")
+	db := logger.Named("db")
+	if jwk.Name() != "authority.provisioner.jwk" {
+		t.Errorf("Logger.Name() = %q, want %q", jwk.Name(), "authority.provisioner.jwk")
+	}
+
+	logger.Debug("root debug")
+	jwk.Debug("jwk debug")
+	db.Warn("db warn")
+	db.Error("db error")
+	if s := buf.String(); strings.Contains(s, "root debug") || !strings.Contains(s, `"logger":"authority.provisioner.jwk","msg":"jwk debug"`) ||
+		strings.Contains(s, "db warn") || !strings.Contains(s, "db error") {
+		t.Errorf("unexpected output %q", s)
+	}
+
+	buf.Reset()
+	if err := logger.SetLevels(map[string]Level{"*": WarnLevel, "authority": ErrorLevel}); err != nil {
+		t.Fatalf("Logger.SetLevels() error = %v", err)
+	}
+	logger.Warn("root warn")
+	jwk.Error("jwk error")
+	logger.Named("other").Warn("other warn")
+	if s := buf.String(); strings.Contains(s, "root warn") || !strings.Contains(s, "jwk error") || strings.Contains(s, "other warn") {
+		t.Errorf("unexpected output %q", s)
+	}
+	if got := logger.Levels(); len(got) != 2 || got["*"] != WarnLevel {
+		t.Errorf("Logger.Levels() = %v", got)
+	}
+}
+
+func TestLogger_SetLevels_conflict(t *testing.T) {
+	logger, err := New("authority", WithLevels(map[string]Level{"authority": DebugLevel}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for _, levels := range []map[string]Level{
+		{"authority": ErrorLevel, "authority.*": DebugLevel},
+		{"authority.": ErrorLevel, "authority": DebugLevel},
+		{"*": ErrorLevel, ".*": DebugLevel},
+	} {
+		if err := logger.SetLevels(levels); err == nil {
+			t.Errorf("Logger.SetLevels(%v) error = nil, want error", levels)
+		}
+		if _, err := New("authority", WithLevels(levels)); err == nil {
+			t.Errorf("New() with levels %v error = nil, want error", levels)
+		}
+	}
+	if got := logger.Levels(); len(got) != 1 || got["authority"] != DebugLevel {
+		t.Errorf("Logger.Levels() = %v, want the levels before the errors", got)
+	}
+}
+
+func TestLevel_text(t *testing.T) {
+	for _, l := range []Level{TraceLevel, DebugLevel, InfoLevel, WarnLevel, ErrorLevel, DPanicLevel, PanicLevel, FatalLevel} {
+		b, err := l.MarshalText()
+		if err != nil {
+			t.Fatalf("Level.MarshalText() error = %v", err)
+		}
+		var got Level
+		if err := got.UnmarshalText(b); err != nil || got != l {
+			t.Errorf("Level.UnmarshalText(%q) = %v, %v, want %v", b, got, err, l)
+		}
+	}
+}
+
+func TestLogger_extendedLevels(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	logger, err := New("test", WithOutput(&stdout), WithErrorOutput(&stderr), WithFormatJSON(), WithLogLevel(TraceLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Trace("trace message")
+	logger.Tracef("trace %s", "format")
+	_, _ = logger.Writer(TraceLevel).Write([]byte("trace writer"))
+	logger.DPanic("dpanic message")
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Logger.Panic() did not panic")
+			}
+		}()
+		logger.Panic("panic message")
+	}()
+
+	if s := stdout.String(); strings.Count(s, `"level":"trace"`) != 3 || !strings.Contains(s, "trace format") {
+		t.Errorf("unexpected stdout %q", s)
+	}
+	if s := stderr.String(); !strings.Contains(s, `"level":"dpanic"`) || !strings.Contains(s, `"level":"panic"`) {
+		t.Errorf("unexpected stderr %q", s)
+	}
+
+	// DPanic panics in development mode
+	logger, err = New("test", WithOutput(&stdout), WithErrorOutput(&stderr), WithDevelopment())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("Logger.DPanic() did not panic in development mode")
+		}
+	}()
+	logger.DPanic("dpanic message")
+}
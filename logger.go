@@ -5,25 +5,31 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"os"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/pkg/errors"
-	"github.com/smallstep/logging/encoder"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-// Level indicates the log level.
+// Level indicates the log level. The values of the levels match the ones of
+// zapcore.Level, with the addition of TraceLevel.
 type Level int8
 
 const (
-	DebugLevel Level = iota - 1
+	// TraceLevel logs are very verbose messages, like wire-level dumps.
+	TraceLevel Level = iota - 2
+	DebugLevel
 	InfoLevel
 	WarnLevel
 	ErrorLevel
+	// DPanicLevel logs are particularly important errors. In development the
+	// logger panics after writing the message.
+	DPanicLevel
+	// PanicLevel logs a message, then panics.
+	PanicLevel
+	// FatalLevel logs a message, then calls os.Exit(1).
 	FatalLevel
 )
 
@@ -35,6 +41,8 @@ func (l Level) MarshalText() ([]byte, error) {
 // String implements [fmt.Stringer] for Level.
 func (l Level) String() string {
 	switch l {
+	case TraceLevel:
+		return "trace"
 	case DebugLevel:
 		return "debug"
 	case InfoLevel:
@@ -43,6 +51,10 @@ func (l Level) String() string {
 		return "warn"
 	case ErrorLevel:
 		return "error"
+	case DPanicLevel:
+		return "dpanic"
+	case PanicLevel:
+		return "panic"
 	case FatalLevel:
 		return "fatal"
 	default:
@@ -50,6 +62,12 @@ func (l Level) String() string {
 	}
 }
 
+// valid returns true if the level is one of the levels defined in this
+// package.
+func (l Level) valid() bool {
+	return l >= TraceLevel && l <= FatalLevel
+}
+
 // UnmarshalText implements [encoding.TextUnmarshaler] for Level.
 func (l *Level) UnmarshalText(text []byte) error {
 	switch lit := strings.ToLower(string(text)); lit {
@@ -65,6 +83,8 @@ func (l *Level) UnmarshalText(text []byte) error {
 		}
 
 		return fmt.Errorf("invalid level: %q", lit)
+	case "trace":
+		*l = TraceLevel
 	case "debug":
 		*l = DebugLevel
 	case "info", "":
@@ -73,6 +93,10 @@ func (l *Level) UnmarshalText(text []byte) error {
 		*l = WarnLevel
 	case "error":
 		*l = ErrorLevel
+	case "dpanic":
+		*l = DPanicLevel
+	case "panic":
+		*l = PanicLevel
 	case "fatal":
 		*l = FatalLevel
 	}
@@ -80,6 +104,12 @@ func (l *Level) UnmarshalText(text []byte) error {
 	return nil
 }
 
+// encodeLevel is a zapcore.LevelEncoder that uses the names of the levels in
+// lowercase, including the trace level.
+func encodeLevel(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	enc.AppendString(Level(l).String())
+}
+
 // DefaultTraceHeader is the default header used as a trace id.
 const DefaultTraceHeader = "Traceparent"
 
@@ -88,6 +118,12 @@ type Logger struct {
 	*zap.Logger
 	name    string
 	options *options
+	levels  *levelSet
+	sinks   []*sinkState
+	hooks   *hookRunner
+	closers []func() error
+	// contextKeys are the keys of the fields added by WithContext.
+	contextKeys map[string]struct{}
 }
 
 type loggerKey struct{}
@@ -111,6 +147,10 @@ type writer struct {
 
 func (w *writer) Write(b []byte) (int, error) {
 	switch w.Level {
+	case TraceLevel:
+		if ce := w.Check(zapcore.Level(TraceLevel), string(b)); ce != nil {
+			ce.Write(w.Name)
+		}
 	case DebugLevel:
 		w.Debug(string(b), w.Name)
 	case InfoLevel:
@@ -119,6 +159,10 @@ func (w *writer) Write(b []byte) (int, error) {
 		w.Warn(string(b), w.Name)
 	case ErrorLevel:
 		w.Error(string(b), w.Name)
+	case DPanicLevel:
+		w.DPanic(string(b), w.Name)
+	case PanicLevel:
+		w.Panic(string(b), w.Name)
 	case FatalLevel:
 		w.Fatal(string(b), w.Name)
 	default:
@@ -128,6 +172,12 @@ func (w *writer) Write(b []byte) (int, error) {
 }
 
 // New initializes the logger with the given options.
+//
+// The default format and level are read from the LOG_FORMAT and LOG_LEVEL
+// environment variables. New does not validate LOG_LEVEL, an invalid level is
+// ignored and the info level is used instead, and an invalid LOG_FORMAT is
+// reported as an unsupported format. Use Config.LoadEnv and FromConfig to load
+// and validate all the LOG_* variables.
 func New(name string, opts ...Option) (*Logger, error) {
 	o := defaultOptions()
 	if err := o.apply(opts); err != nil {
@@ -135,69 +185,233 @@ func New(name string, opts ...Option) (*Logger, error) {
 	}
 
 	config := zap.NewProductionEncoderConfig()
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	config.EncodeLevel = encodeLevel
+	o.Caller.encoderConfig(&config)
+	o.Encoding.encoderConfig(&config)
 
-	var outEncoder, errEncoder zapcore.Encoder
-	switch strings.ToLower(o.Format) {
-	case "", "text", "docker":
-		outEncoder = encoder.NewTextEncoder(config)
-		errEncoder = encoder.NewTextEncoder(config)
-	case "json", "k8s", "kubernetes":
-		outEncoder = zapcore.NewJSONEncoder(config)
-		errEncoder = zapcore.NewJSONEncoder(config)
-	case "common":
-		outEncoder = encoder.NewCLFEncoder(config)
-		errEncoder = encoder.NewCLFEncoder(config)
-	default:
-		return nil, errors.Errorf("unsupported logger.format '%s'", o.Format)
+	levels := newLevelSet(o.Level, o.Levels, o.Sampling)
+
+	sinks := o.Sinks
+	if len(sinks) == 0 {
+		sinks = defaultSinks(o)
 	}
 
-	minLogLevel := zapcore.Level(o.Level) // one-to-one mapping
+	cores := make([]zapcore.Core, 0, len(sinks))
+	states := make([]*sinkState, 0, len(sinks))
+	closers := make([]func() error, 0, len(sinks)+1)
 
-	// Logs info and debug to stdout
-	outWriter := zapcore.Lock(os.Stdout)
-	outLevel := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-		return lvl >= minLogLevel && lvl < zapcore.WarnLevel
-	})
+	var hooks *hookRunner
+	fns := o.hooks
+	if o.stats != nil {
+		fns = append(fns[:len(fns):len(fns)], o.stats.hook)
+	}
+	if len(fns) > 0 {
+		hooks = newHookRunner(fns)
+		closers = append(closers, hooks.Close)
+	}
 
-	// Logs warning and errors to stderr
-	errWriter := zapcore.Lock(os.Stderr)
-	errLevel := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-		return lvl >= minLogLevel && lvl >= zapcore.WarnLevel
-	})
+	for _, s := range sinks {
+		state := &sinkState{
+			name:    s.name(),
+			sampler: newSampler(levels),
+		}
+		core, closer, err := newSinkCore(name, s, o, config, state, hooks)
+		if err != nil {
+			closeAll(closers)
+			return nil, err
+		}
+		cores = append(cores, &samplerCore{Core: core, sampler: state.sampler})
+		states = append(states, state)
+		if closer != nil {
+			closers = append(closers, closer)
+		}
+	}
+
+	core := zapcore.NewTee(cores...)
+	if o.Dedup != nil {
+		state := newDedupState(o.Dedup, o.now)
+		core = newDedupCore(core, state)
+		// The summaries must be written before closing the sinks.
+		closers = append([]func() error{state.Close}, closers...)
+	}
 
 	// Create zap.Logger
-	logger := zap.New(zapcore.NewTee(
-		zapcore.NewCore(outEncoder, outWriter, outLevel),
-		zapcore.NewCore(errEncoder, errWriter, errLevel),
-	)).WithOptions(zap.AddCallerSkip(o.CallerSkip))
+	core = newLevelCore(core, levels, name)
+	zapOpts := append([]zap.Option{zap.AddCallerSkip(o.CallerSkip)}, o.callerOptions()...)
+	if o.clock != nil {
+		zapOpts = append(zapOpts, zap.WithClock(o.clock))
+	}
+	if fields := o.staticFields(); len(fields) > 0 {
+		zapOpts = append(zapOpts, zap.Fields(fields...))
+	}
+	if o.Development {
+		zapOpts = append(zapOpts, zap.Development())
+	}
+	if o.fatalHook != nil {
+		zapOpts = append(zapOpts, zap.WithFatalHook(o.fatalHook))
+	}
+	logger := zap.New(core, zapOpts...)
 
-	return &Logger{
+	l := &Logger{
 		Logger:  logger,
 		name:    name,
 		options: o,
-	}, nil
+		levels:  levels,
+		sinks:   states,
+		hooks:   hooks,
+		closers: closers,
+	}
+	if o.stats != nil {
+		o.stats.attach(l)
+	}
+	return l, nil
+}
+
+func closeAll(closers []func() error) (err error) {
+	for _, fn := range closers {
+		if e := fn(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return
 }
 
 // Clone creates a new copy of the logger with the given options.
 func (l *Logger) Clone(opts ...zap.Option) *Logger {
 	return &Logger{
-		Logger:  l.Logger.WithOptions(opts...),
-		name:    l.name,
-		options: l.options,
+		Logger:      l.Logger.WithOptions(opts...),
+		name:        l.name,
+		options:     l.options,
+		levels:      l.levels,
+		sinks:       l.sinks,
+		hooks:       l.hooks,
+		closers:     l.closers,
+		contextKeys: l.contextKeys,
 	}
 }
 
 // Sync calls the underlying Core's Sync method, flushing any buffered log
-// entries. Applications should take care to call Sync before exiting.
+// entries. Applications should take care to call Sync before exiting. If the
+// logger is asynchronous, Sync waits until the queues are drained or the
+// flush timeout expires.
 func (l *Logger) Sync() error {
 	return l.Logger.Sync()
 }
 
+// Close flushes any buffered log entries and closes the outputs opened by the
+// logger. The logger must not be used after calling Close.
+func (l *Logger) Close() error {
+	err := l.Sync()
+	if e := closeAll(l.closers); e != nil {
+		err = e
+	}
+	return err
+}
+
 // Name returns the logging name.
 func (l *Logger) Name() string {
 	return l.name
 }
 
+// Named creates a child logger that appends the given name to the name of the
+// logger using a dot as a separator. The level of the child logger is the one
+// of the longest matching name in the level overrides, or the logger level if
+// none matches.
+func (l *Logger) Named(name string) *Logger {
+	if name == "" {
+		return l
+	}
+	fullName := name
+	if l.name != "" {
+		fullName = l.name + "." + name
+	}
+
+	logger := l.Logger
+	if logger.Name() == "" {
+		logger = logger.Named(fullName)
+	} else {
+		logger = logger.Named(name)
+	}
+
+	return &Logger{
+		Logger: logger.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+			if lc, ok := c.(*levelCore); ok {
+				return lc.named(fullName)
+			}
+			return newLevelCore(c, l.levels, fullName)
+		})),
+		name:        fullName,
+		options:     l.options,
+		levels:      l.levels,
+		sinks:       l.sinks,
+		hooks:       l.hooks,
+		closers:     l.closers,
+		contextKeys: l.contextKeys,
+	}
+}
+
+// Level returns the minimum level enabled in the logger. It does not include
+// the level overrides.
+func (l *Logger) Level() Level {
+	return l.levels.getLevel()
+}
+
+// SetLevel changes the minimum level enabled in the logger. The change
+// applies to all the loggers created from the same logger.
+func (l *Logger) SetLevel(level Level) {
+	l.levels.setLevel(level)
+}
+
+// Levels returns the level overrides by logger name.
+func (l *Logger) Levels() map[string]Level {
+	return l.levels.getOverrides()
+}
+
+// SetLevels replaces the level overrides by logger name. A name matches itself
+// and its descendants, "authority" and "authority.*" both match "authority"
+// and "authority.provisioner", and the longest match wins. The name "*"
+// matches all the loggers. Two names that match the same loggers, like
+// "authority" and "authority.*", return an error. The change applies to all
+// the loggers created from the same logger.
+func (l *Logger) SetLevels(levels map[string]Level) error {
+	v := new(validator)
+	validateLevels(v, "levels", levels)
+	if err := v.err(); err != nil {
+		return err
+	}
+	l.levels.setOverrides(levels)
+	return nil
+}
+
+// Sampling returns the sampling configuration of the logger, nil if sampling
+// is disabled.
+func (l *Logger) Sampling() *Sampling {
+	return l.levels.getSampling()
+}
+
+// SetSampling changes the sampling configuration of the logger, a nil value
+// disables sampling. The change applies to all the loggers created from the
+// same logger.
+func (l *Logger) SetSampling(s *Sampling) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	l.levels.setSampling(s)
+	return nil
+}
+
+// SinkStats returns the statistics of each sink of the logger.
+func (l *Logger) SinkStats() []SinkStats {
+	stats := make([]SinkStats, len(l.sinks))
+	for i, s := range l.sinks {
+		stats[i] = s.stats()
+	}
+	return stats
+}
+
 // TraceHeader returns the trace header configured.
 func (l *Logger) TraceHeader() string {
 	if l.options.TraceHeader == "" {
@@ -224,6 +438,11 @@ func (l *Logger) TimeFormat() string {
 	return l.options.TimeFormat
 }
 
+// Now returns the current time using the clock of the logger.
+func (l *Logger) Now() time.Time {
+	return l.options.now()
+}
+
 // Writer returns a io.Writer with the specified log level.
 func (l *Logger) Writer(level Level) io.Writer {
 	return &writer{
@@ -238,6 +457,13 @@ func (l *Logger) StdLogger(level Level) *log.Logger {
 	return log.New(l.Writer(level), "", 0)
 }
 
+// Trace logs a message at trace level.
+func (l *Logger) Trace(msg string, fields ...zap.Field) {
+	if ce := l.Check(zapcore.Level(TraceLevel), msg); ce != nil {
+		ce.Write(fields...)
+	}
+}
+
 // Debug logs a message at debug level.
 func (l *Logger) Debug(msg string, fields ...zap.Field) {
 	l.Logger.Debug(msg, fields...)
@@ -258,11 +484,29 @@ func (l *Logger) Error(msg string, fields ...zap.Field) {
 	l.Logger.Error(msg, fields...)
 }
 
+// DPanic logs a message at dpanic level. If the logger is in development
+// mode, it then panics.
+func (l *Logger) DPanic(msg string, fields ...zap.Field) {
+	l.Logger.DPanic(msg, fields...)
+}
+
+// Panic logs a message at panic level and then panics.
+func (l *Logger) Panic(msg string, fields ...zap.Field) {
+	l.Logger.Panic(msg, fields...)
+}
+
 // Fatal logs a message at fatal level and then calls to os.Exit(1).
 func (l *Logger) Fatal(msg string, fields ...zap.Field) {
 	l.Logger.Fatal(msg, fields...)
 }
 
+// Tracef formats and logs a message at trace level.
+func (l *Logger) Tracef(format string, args ...interface{}) {
+	if ce := l.Check(zapcore.Level(TraceLevel), fmt.Sprintf(format, args...)); ce != nil {
+		ce.Write()
+	}
+}
+
 // Debugf formats and logs a message at debug level.
 func (l *Logger) Debugf(format string, args ...interface{}) {
 	l.Logger.Debug(fmt.Sprintf(format, args...))
@@ -288,3 +532,95 @@ func (l *Logger) Errorf(format string, args ...interface{}) {
 func (l *Logger) Fatalf(format string, args ...interface{}) {
 	l.Logger.Fatal(fmt.Sprintf(format, args...))
 }
+
+// WithContext returns a copy of the logger that adds the request-id,
+// tracing-id, name and span fields in the given context to all the entries.
+// The *Context methods of the returned logger do not repeat these fields.
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	fields := l.contextFields(ctx)
+	keys := make(map[string]struct{}, len(l.contextKeys)+len(fields))
+	for k := range l.contextKeys {
+		keys[k] = struct{}{}
+	}
+	for _, f := range fields {
+		keys[f.Key] = struct{}{}
+	}
+	logger := l.Clone(zap.Fields(fields...))
+	logger.contextKeys = keys
+	return logger
+}
+
+// contextFields returns the fields in the context that have not been already
+// added with WithContext.
+func (l *Logger) contextFields(ctx context.Context) []zap.Field {
+	fields := ContextFields(ctx, l.name)
+	if len(l.contextKeys) == 0 {
+		return fields
+	}
+	result := fields[:0]
+	for _, f := range fields {
+		if _, ok := l.contextKeys[f.Key]; !ok {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// TraceContext logs a message at trace level with the fields in the context.
+func (l *Logger) TraceContext(ctx context.Context, msg string, fields ...zap.Field) {
+	if ce := l.Check(zapcore.Level(TraceLevel), msg); ce != nil {
+		ce.Write(append(l.contextFields(ctx), fields...)...)
+	}
+}
+
+// DebugContext logs a message at debug level with the fields in the context.
+func (l *Logger) DebugContext(ctx context.Context, msg string, fields ...zap.Field) {
+	if ce := l.Check(zapcore.DebugLevel, msg); ce != nil {
+		ce.Write(append(l.contextFields(ctx), fields...)...)
+	}
+}
+
+// InfoContext logs a message at info level with the fields in the context.
+func (l *Logger) InfoContext(ctx context.Context, msg string, fields ...zap.Field) {
+	if ce := l.Check(zapcore.InfoLevel, msg); ce != nil {
+		ce.Write(append(l.contextFields(ctx), fields...)...)
+	}
+}
+
+// WarnContext logs a message at warn level with the fields in the context.
+func (l *Logger) WarnContext(ctx context.Context, msg string, fields ...zap.Field) {
+	if ce := l.Check(zapcore.WarnLevel, msg); ce != nil {
+		ce.Write(append(l.contextFields(ctx), fields...)...)
+	}
+}
+
+// ErrorContext logs a message at error level with the fields in the context.
+func (l *Logger) ErrorContext(ctx context.Context, msg string, fields ...zap.Field) {
+	if ce := l.Check(zapcore.ErrorLevel, msg); ce != nil {
+		ce.Write(append(l.contextFields(ctx), fields...)...)
+	}
+}
+
+// DPanicContext logs a message at dpanic level with the fields in the
+// context. If the logger is in development mode, it then panics.
+func (l *Logger) DPanicContext(ctx context.Context, msg string, fields ...zap.Field) {
+	if ce := l.Check(zapcore.DPanicLevel, msg); ce != nil {
+		ce.Write(append(l.contextFields(ctx), fields...)...)
+	}
+}
+
+// PanicContext logs a message at panic level with the fields in the context
+// and then panics.
+func (l *Logger) PanicContext(ctx context.Context, msg string, fields ...zap.Field) {
+	if ce := l.Check(zapcore.PanicLevel, msg); ce != nil {
+		ce.Write(append(l.contextFields(ctx), fields...)...)
+	}
+}
+
+// FatalContext logs a message at fatal level with the fields in the context
+// and then calls to os.Exit(1).
+func (l *Logger) FatalContext(ctx context.Context, msg string, fields ...zap.Field) {
+	if ce := l.Check(zapcore.FatalLevel, msg); ce != nil {
+		ce.Write(append(l.contextFields(ctx), fields...)...)
+	}
+}
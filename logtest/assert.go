@@ -0,0 +1,147 @@
+package logtest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// HTTPEntry contains the fields of an entry written by the httplog
+// middleware.
+type HTTPEntry struct {
+	Name          string
+	RequestID     string
+	TracingID     string
+	RemoteAddress string
+	Time          string
+	Duration      time.Duration
+	Method        string
+	Path          string
+	Protocol      string
+	Status        int64
+	Size          int64
+	Referer       string
+	UserAgent     string
+}
+
+// HTTP returns the httplog fields of the entry, and false if the entry has
+// not been written by the httplog middleware.
+func (e Entry) HTTP() (HTTPEntry, bool) {
+	if e.String("system") != "http" {
+		return HTTPEntry{}, false
+	}
+	return HTTPEntry{
+		Name:          e.String("name"),
+		RequestID:     e.String("request-id"),
+		TracingID:     e.String("tracing-id"),
+		RemoteAddress: e.String("remote-address"),
+		Time:          e.String("time"),
+		Duration:      e.Duration("duration"),
+		Method:        e.String("method"),
+		Path:          e.String("path"),
+		Protocol:      e.String("protocol"),
+		Status:        e.Int("status"),
+		Size:          e.Int("size"),
+		Referer:       e.String("referer"),
+		UserAgent:     e.String("user-agent"),
+	}, true
+}
+
+// GRPCEntry contains the fields of an entry written by the grpclog
+// interceptors.
+type GRPCEntry struct {
+	Name        string
+	RequestID   string
+	TracingID   string
+	Package     string
+	Service     string
+	Method      string
+	Code        string
+	Time        string
+	Duration    time.Duration
+	PeerAddress string
+}
+
+// GRPC returns the grpclog fields of the entry, and false if the entry has
+// not been written by the grpclog interceptors.
+func (e Entry) GRPC() (GRPCEntry, bool) {
+	if e.String("system") != "grpc" {
+		return GRPCEntry{}, false
+	}
+	return GRPCEntry{
+		Name:        e.String("name"),
+		RequestID:   e.String("request-id"),
+		TracingID:   e.String("tracing-id"),
+		Package:     e.String("grpc.package"),
+		Service:     e.String("grpc.service"),
+		Method:      e.String("grpc.method"),
+		Code:        e.String("grpc.code"),
+		Time:        e.String("time"),
+		Duration:    e.Duration("durations"),
+		PeerAddress: e.String("peer.address"),
+	}, true
+}
+
+// HTTP returns the entries written by the httplog middleware.
+func (es Entries) HTTP() Entries {
+	return es.FilterField("system", "http")
+}
+
+// GRPC returns the entries written by the grpclog interceptors.
+func (es Entries) GRPC() Entries {
+	return es.FilterField("system", "grpc")
+}
+
+// AssertHTTP checks that the entry has been written by the httplog middleware
+// and that it matches the non-zero fields in want. It reports the differences
+// using t.Errorf and returns false if there are any.
+func AssertHTTP(t testing.TB, e Entry, want HTTPEntry) bool {
+	t.Helper()
+	got, ok := e.HTTP()
+	if !ok {
+		t.Errorf("entry %q is not an http entry", e.Message)
+		return false
+	}
+	return assertFields(t, "http", got, want)
+}
+
+// AssertGRPC checks that the entry has been written by the grpclog
+// interceptors and that it matches the non-zero fields in want. It reports
+// the differences using t.Errorf and returns false if there are any.
+func AssertGRPC(t testing.TB, e Entry, want GRPCEntry) bool {
+	t.Helper()
+	got, ok := e.GRPC()
+	if !ok {
+		t.Errorf("entry %q is not a grpc entry", e.Message)
+		return false
+	}
+	return assertFields(t, "grpc", got, want)
+}
+
+// AssertCount checks that the number of entries is n.
+func AssertCount(t testing.TB, es Entries, n int) bool {
+	t.Helper()
+	if len(es) != n {
+		t.Errorf("got %d entries, want %d: %q", len(es), n, es.Messages())
+		return false
+	}
+	return true
+}
+
+// assertFields compares the non-zero fields of want with the ones in got.
+func assertFields(t testing.TB, kind string, got, want interface{}) bool {
+	t.Helper()
+	ok := true
+	gv, wv := reflect.ValueOf(got), reflect.ValueOf(want)
+	for i := 0; i < wv.NumField(); i++ {
+		w := wv.Field(i)
+		if w.IsZero() {
+			continue
+		}
+		if g := gv.Field(i); g.Interface() != w.Interface() {
+			t.Errorf("%s entry %s = %v, want %v", kind, wv.Type().Field(i).Name, g.Interface(), w.Interface())
+			ok = false
+		}
+	}
+	return ok
+}
@@ -0,0 +1,245 @@
+// Package logtest provides a logging.Logger that records the entries in
+// memory, and helpers to query and assert them in tests.
+package logtest
+
+import (
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/smallstep/logging"
+	"github.com/smallstep/logging/internal/testclock"
+)
+
+// Recorder keeps the entries written by a logger created with New.
+type Recorder struct {
+	logs *observer.ObservedLogs
+}
+
+// New creates a logger with the given name that records all the entries in
+// the returned Recorder instead of writing them to stdout and stderr. Fatal
+// entries panic instead of calling os.Exit. The given options are applied
+// after the defaults, use logging.WithClock with a Clock to get deterministic
+// times and durations.
+//
+// The entries are recorded before they are encoded, so the error fields are
+// not rendered with their causes and stack traces like in the sinks of the
+// logger. An error field is recorded with the message of the error, and, as in
+// zap, with an additional "<key>Verbose" field if the error implements
+// fmt.Formatter.
+func New(name string, opts ...logging.Option) (*logging.Logger, *Recorder) {
+	core, logs := observer.New(zap.LevelEnablerFunc(func(zapcore.Level) bool {
+		return true
+	}))
+
+	defaults := []logging.Option{
+		logging.WithLogLevel(logging.DebugLevel),
+		logging.WithCore("logtest", core, logging.AllLevels()),
+		logging.WithFatalHook(zapcore.WriteThenPanic),
+	}
+	logger, err := logging.New(name, append(defaults, opts...)...)
+	if err != nil {
+		panic(err)
+	}
+	return logger, &Recorder{logs: logs}
+}
+
+// All returns all the entries recorded.
+func (r *Recorder) All() Entries {
+	return newEntries(r.logs.All())
+}
+
+// TakeAll returns all the entries recorded and resets the recorder.
+func (r *Recorder) TakeAll() Entries {
+	return newEntries(r.logs.TakeAll())
+}
+
+// Len returns the number of entries recorded.
+func (r *Recorder) Len() int {
+	return r.logs.Len()
+}
+
+// Entry is a recorded log entry.
+type Entry struct {
+	Level   logging.Level
+	Time    time.Time
+	Name    string
+	Message string
+	Caller  zapcore.EntryCaller
+	Stack   string
+	// Fields contains the fields of the entry, objects are represented as
+	// maps.
+	Fields map[string]interface{}
+}
+
+func newEntry(e observer.LoggedEntry) Entry {
+	return Entry{
+		Level:   logging.Level(e.Level),
+		Time:    e.Time,
+		Name:    e.LoggerName,
+		Message: e.Message,
+		Caller:  e.Caller,
+		Stack:   e.Stack,
+		Fields:  e.ContextMap(),
+	}
+}
+
+// Field returns the value of the field with the given key.
+func (e Entry) Field(key string) (interface{}, bool) {
+	v, ok := e.Fields[key]
+	return v, ok
+}
+
+// String returns the value of the field with the given key as a string, or
+// an empty string if the field is not present or it's not a string.
+func (e Entry) String(key string) string {
+	s, _ := e.Fields[key].(string)
+	return s
+}
+
+// Int returns the value of the field with the given key as an int64, or 0 if
+// the field is not present or it's not an integer.
+func (e Entry) Int(key string) int64 {
+	i, _ := toInt64(e.Fields[key])
+	return i
+}
+
+// Duration returns the value of the field with the given key as a duration,
+// or 0 if the field is not present or it's not a duration.
+func (e Entry) Duration(key string) time.Duration {
+	d, _ := e.Fields[key].(time.Duration)
+	return d
+}
+
+// RequestID returns the request-id field of the entry.
+func (e Entry) RequestID() string {
+	return e.String("request-id")
+}
+
+// Entries is a list of recorded entries.
+type Entries []Entry
+
+func newEntries(logs []observer.LoggedEntry) Entries {
+	entries := make(Entries, len(logs))
+	for i := range logs {
+		entries[i] = newEntry(logs[i])
+	}
+	return entries
+}
+
+// Filter returns the entries for which fn returns true.
+func (es Entries) Filter(fn func(Entry) bool) Entries {
+	var filtered Entries
+	for _, e := range es {
+		if fn(e) {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// FilterLevel returns the entries with the given level.
+func (es Entries) FilterLevel(level logging.Level) Entries {
+	return es.Filter(func(e Entry) bool {
+		return e.Level == level
+	})
+}
+
+// FilterMessage returns the entries with the given message.
+func (es Entries) FilterMessage(msg string) Entries {
+	return es.Filter(func(e Entry) bool {
+		return e.Message == msg
+	})
+}
+
+// FilterMessageContains returns the entries with a message that contains the
+// given substring.
+func (es Entries) FilterMessageContains(substr string) Entries {
+	return es.Filter(func(e Entry) bool {
+		return strings.Contains(e.Message, substr)
+	})
+}
+
+// FilterName returns the entries written by the logger with the given name.
+func (es Entries) FilterName(name string) Entries {
+	return es.Filter(func(e Entry) bool {
+		return e.Name == name
+	})
+}
+
+// FilterField returns the entries with a field with the given key and value.
+// Integers are compared as int64.
+func (es Entries) FilterField(key string, value interface{}) Entries {
+	return es.Filter(func(e Entry) bool {
+		v, ok := e.Fields[key]
+		if !ok {
+			return false
+		}
+		if i, ok := toInt64(value); ok {
+			j, ok := toInt64(v)
+			return ok && i == j
+		}
+		return v == value
+	})
+}
+
+// FilterFieldKey returns the entries with a field with the given key.
+func (es Entries) FilterFieldKey(key string) Entries {
+	return es.Filter(func(e Entry) bool {
+		_, ok := e.Fields[key]
+		return ok
+	})
+}
+
+// FilterRequestID returns the entries with the given request-id.
+func (es Entries) FilterRequestID(id string) Entries {
+	return es.FilterField("request-id", id)
+}
+
+// Messages returns the messages of the entries.
+func (es Entries) Messages() []string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Message
+	}
+	return msgs
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	switch v := v.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// Clock is a zapcore.Clock that only advances when it's told to. It's safe
+// for concurrent use.
+type Clock = testclock.Clock
+
+// NewClock returns a clock set to the given time.
+func NewClock(t time.Time) *Clock {
+	return testclock.New(t)
+}
@@ -0,0 +1,124 @@
+package logtest_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/smallstep/logging"
+	"github.com/smallstep/logging/grpclog"
+	"github.com/smallstep/logging/httplog"
+	"github.com/smallstep/logging/logtest"
+	"github.com/smallstep/logging/requestid"
+)
+
+var testTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func TestNew(t *testing.T) {
+	logger, rec := logtest.New("test")
+	logger.Debug("debug message")
+	logger.Info("info message", zap.String("key", "value"))
+	logger.Named("child").Warn("warn message", zap.Int("n", 1))
+	logger.InfoContext(requestid.NewContext(context.Background(), "req-1"), "with request")
+
+	all := rec.All()
+	logtest.AssertCount(t, all, 4)
+	logtest.AssertCount(t, all.FilterLevel(logging.WarnLevel), 1)
+	logtest.AssertCount(t, all.FilterMessage("info message"), 1)
+	logtest.AssertCount(t, all.FilterMessageContains("message"), 3)
+	logtest.AssertCount(t, all.FilterField("key", "value"), 1)
+	logtest.AssertCount(t, all.FilterField("n", 1), 1)
+	logtest.AssertCount(t, all.FilterName("test.child"), 1)
+	logtest.AssertCount(t, all.FilterRequestID("req-1"), 1)
+
+	if n := len(rec.TakeAll()); n != 4 || rec.Len() != 0 {
+		t.Errorf("Recorder.TakeAll() = %d entries, Recorder.Len() = %d", n, rec.Len())
+	}
+}
+
+func TestNew_fatal(t *testing.T) {
+	logger, rec := logtest.New("test")
+	defer func() {
+		if recover() == nil {
+			t.Error("Logger.Fatal() did not panic")
+		}
+		logtest.AssertCount(t, rec.All().FilterLevel(logging.FatalLevel), 1)
+	}()
+	logger.Fatal("fatal message")
+}
+
+func TestNew_error(t *testing.T) {
+	logger, rec := logtest.New("test")
+	logger.Error("error message", zap.Error(errors.Wrap(errors.New("cause"), "wrapped")))
+
+	e := rec.All()[0]
+	if s := e.String("error"); s != "wrapped: cause" {
+		t.Errorf("error = %q, want %q", s, "wrapped: cause")
+	}
+	if _, ok := e.Field("errorVerbose"); !ok {
+		t.Error("errorVerbose field not found")
+	}
+}
+
+func TestAssertHTTP(t *testing.T) {
+	clock := logtest.NewClock(testTime)
+	logger, rec := logtest.New("test", logging.WithClock(clock))
+
+	h := httplog.Middleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clock.Add(250 * time.Millisecond)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/foo", http.NoBody))
+
+	entries := rec.All().HTTP()
+	if logtest.AssertCount(t, entries, 1) {
+		logtest.AssertHTTP(t, entries[0], logtest.HTTPEntry{
+			Name:     "test",
+			Method:   "GET",
+			Path:     "/foo",
+			Status:   http.StatusNotFound,
+			Time:     testTime.Format(time.RFC3339),
+			Duration: 250 * time.Millisecond,
+		})
+		if entries[0].Level != logging.WarnLevel || !entries[0].Time.Equal(testTime.Add(250*time.Millisecond)) {
+			t.Errorf("unexpected entry %v", entries[0])
+		}
+	}
+}
+
+func TestAssertGRPC(t *testing.T) {
+	clock := logtest.NewClock(testTime)
+	logger, rec := logtest.New("test", logging.WithClock(clock))
+
+	interceptor := grpclog.UnaryServerInterceptor(logger)
+	_, _ = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{
+		FullMethod: "/foo.bar.Service/Method",
+	}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		clock.Add(time.Second)
+		return nil, status.Error(codes.Internal, "internal error")
+	})
+
+	entries := rec.All().GRPC()
+	if logtest.AssertCount(t, entries, 1) {
+		logtest.AssertGRPC(t, entries[0], logtest.GRPCEntry{
+			Name:     "test",
+			Package:  "foo.bar",
+			Service:  "Service",
+			Method:   "Method",
+			Code:     "Internal",
+			Time:     testTime.Format(time.RFC3339),
+			Duration: time.Second,
+		})
+		if entries[0].Level != logging.ErrorLevel || entries[0].RequestID() == "" {
+			t.Errorf("unexpected entry %v", entries[0])
+		}
+	}
+}
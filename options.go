@@ -2,27 +2,37 @@ package logging
 
 import (
 	"encoding/json"
+	"io"
 	"os"
+	"time"
 
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 type options struct {
-	Format       string `json:"format"`
-	Level        Level  `json:"level"`
-	TraceHeader  string `json:"traceHeader"`
-	LogRequests  bool   `json:"logRequests"`
-	LogResponses bool   `json:"logResponses"`
-	TimeFormat   string `json:"timeFormat"`
-	CallerSkip   int    `json:"callerSkip"`
+	Config
+
+	output      io.Writer
+	errorOutput io.Writer
+	clock       zapcore.Clock
+	fatalHook   zapcore.CheckWriteHook
+	hooks       []Hook
+	stats       *Stats
+	fields      []zap.Field
 }
 
+// defaultOptions returns the default configuration with the format and level
+// defined in LOG_FORMAT and LOG_LEVEL. An invalid level is ignored and an
+// invalid format fails in the validation of the options, use Config.LoadEnv to
+// validate both.
 func defaultOptions() *options {
+	c := DefaultConfig()
+	c.Format = formatFromEnv()
+	c.Level = levelFromEnv()
 	return &options{
-		Format:      formatFromEnv(),
-		Level:       levelFromEnv(),
-		TraceHeader: DefaultTraceHeader,
-		CallerSkip:  1,
+		Config: *c,
 	}
 }
 
@@ -42,6 +52,14 @@ func levelFromEnv() (level Level) {
 	return
 }
 
+// now returns the current time using the configured clock.
+func (o *options) now() time.Time {
+	if o.clock == nil {
+		return time.Now()
+	}
+	return o.clock.Now()
+}
+
 func (o *options) apply(opts []Option) (err error) {
 	for _, fn := range opts {
 		if err = fn(o); err != nil {
@@ -51,6 +69,38 @@ func (o *options) apply(opts []Option) (err error) {
 	return
 }
 
+// Duration is a wrapper around time.Duration that can be unmarshaled from a
+// JSON string like "5m" or from a number of nanoseconds.
+type Duration struct {
+	time.Duration
+}
+
+// MarshalJSON implements [json.Marshaler] for Duration.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Duration.String())
+}
+
+// UnmarshalJSON implements [json.Unmarshaler] for Duration.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return errors.Wrapf(err, "error parsing %s as duration", data)
+	}
+	switch v := v.(type) {
+	case float64:
+		d.Duration = time.Duration(v)
+	case string:
+		dd, err := time.ParseDuration(v)
+		if err != nil {
+			return errors.Wrapf(err, "error parsing %s as duration", v)
+		}
+		d.Duration = dd
+	default:
+		return errors.Errorf("invalid duration %s", data)
+	}
+	return nil
+}
+
 // Option is the type used to modify logger options.
 type Option func(o *options) error
 
@@ -64,6 +114,16 @@ func WithConfig(raw json.RawMessage) Option {
 	}
 }
 
+// FromConfig configures the logger using the given configuration. Fields not
+// set in c will use their zero value, use DefaultConfig to get a configuration
+// with the default values.
+func FromConfig(c *Config) Option {
+	return func(o *options) error {
+		o.Config = *c
+		return nil
+	}
+}
+
 // WithFormatText configures the format of the logs as text. Defaults to text.
 func WithFormatText() Option {
 	return func(o *options) error {
@@ -80,7 +140,9 @@ func WithFormatJSON() Option {
 	}
 }
 
-// WithTimeFormat sets a specific format for the time fields.
+// WithTimeFormat sets a specific format for the time fields written by the
+// HTTP and gRPC loggers. Use WithEncoding to configure the time of the
+// entries.
 func WithTimeFormat(format string) Option {
 	return func(o *options) error {
 		o.TimeFormat = format
@@ -121,6 +183,24 @@ func WithCallerSkip(skip int) Option {
 	}
 }
 
+// WithErrorMaxDepth sets the maximum depth of the causes rendered in the error
+// fields.
+func WithErrorMaxDepth(depth int) Option {
+	return func(o *options) error {
+		o.ErrorMaxDepth = depth
+		return nil
+	}
+}
+
+// WithDevelopment enables the development mode, where the entries at dpanic
+// level panic after being written.
+func WithDevelopment() Option {
+	return func(o *options) error {
+		o.Development = true
+		return nil
+	}
+}
+
 // WithLogLevel sets the verbosity of the logger.
 func WithLogLevel(level Level) Option {
 	return func(o *options) error {
@@ -129,3 +209,104 @@ func WithLogLevel(level Level) Option {
 		return nil
 	}
 }
+
+// WithOutput sets the writer used for debug and info entries. Defaults to
+// os.Stdout. It does not apply if sinks are configured.
+func WithOutput(w io.Writer) Option {
+	return func(o *options) error {
+		o.output = w
+		return nil
+	}
+}
+
+// WithErrorOutput sets the writer used for warning and error entries. Defaults
+// to os.Stderr. It does not apply if sinks are configured.
+func WithErrorOutput(w io.Writer) Option {
+	return func(o *options) error {
+		o.errorOutput = w
+		return nil
+	}
+}
+
+// WithSink adds a new sink that writes the entries in the given range of levels
+// to w using the given format. If format is empty the format of the logger
+// will be used. Sinks replace the default stdout and stderr outputs, but
+// multiple sinks can be added to write to several destinations.
+func WithSink(name string, w io.Writer, levels LevelRange, format string) Option {
+	return func(o *options) error {
+		o.Sinks = append(o.Sinks, &Sink{
+			Name:   name,
+			Format: format,
+			Levels: levels,
+			Writer: w,
+		})
+		return nil
+	}
+}
+
+// WithCore adds a new sink that writes the entries in the given range of
+// levels to the given core. Like WithSink, it replaces the default stdout and
+// stderr outputs.
+func WithCore(name string, core zapcore.Core, levels LevelRange) Option {
+	return func(o *options) error {
+		o.Sinks = append(o.Sinks, &Sink{
+			Name:   name,
+			Levels: levels,
+			Core:   core,
+		})
+		return nil
+	}
+}
+
+// WithLevels sets the levels of the loggers with the given names, overriding
+// the level of the logger. See [Logger.SetLevels] for the format of the names.
+func WithLevels(levels map[string]Level) Option {
+	return func(o *options) error {
+		o.Levels = levels
+		return nil
+	}
+}
+
+// WithSampling enables the sampling of entries with the given configuration.
+func WithSampling(s *Sampling) Option {
+	return func(o *options) error {
+		o.Sampling = s
+		return nil
+	}
+}
+
+// WithAsync enables the asynchronous writing of entries with the given
+// configuration.
+func WithAsync(a *Async) Option {
+	return func(o *options) error {
+		o.Async = a
+		return nil
+	}
+}
+
+// WithDedup enables the deduplication of repeated entries with the given
+// configuration.
+func WithDedup(d *Dedup) Option {
+	return func(o *options) error {
+		o.Dedup = d
+		return nil
+	}
+}
+
+// WithClock sets the clock used to get the time of the entries and the time
+// and duration of the requests. Defaults to the system clock.
+func WithClock(clock zapcore.Clock) Option {
+	return func(o *options) error {
+		o.clock = clock
+		return nil
+	}
+}
+
+// WithFatalHook sets the action taken after writing a fatal entry. Defaults to
+// os.Exit(1); tests can use zapcore.WriteThenPanic to recover from it.
+func WithFatalHook(hook zapcore.CheckWriteHook) Option {
+	return func(o *options) error {
+		o.fatalHook = hook
+		return nil
+	}
+}
@@ -0,0 +1,163 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultWatchInterval is the default interval used to poll a configuration
+// file.
+const defaultWatchInterval = 10 * time.Second
+
+// ApplyConfig changes the level, the level overrides and the sampling of the
+// logger to the ones in the given configuration. The configuration is
+// validated first, and nothing is changed if it's not valid. The three
+// settings are changed atomically. The rest of the fields cannot be changed at
+// runtime and they are ignored. Each change is logged at info level, even if
+// the new level is higher.
+func (l *Logger) ApplyConfig(c *Config) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	old := l.levels.set(c.Level, c.Levels, c.Sampling)
+
+	if old.level != c.Level {
+		l.logChange("logging level changed",
+			zap.Stringer("from", old.level), zap.Stringer("to", c.Level))
+	}
+	if !levelsEqual(old.levels, c.Levels) {
+		l.logChange("logging level overrides changed",
+			zap.Any("from", old.levels), zap.Any("to", c.Levels))
+	}
+	if !reflect.DeepEqual(old.sampling, c.Sampling) {
+		l.logChange("logging sampling changed",
+			zap.Any("from", old.sampling), zap.Any("to", c.Sampling))
+	}
+	return nil
+}
+
+// logChange writes a configuration change at info level, skipping the level
+// of the logger so the change is recorded even if info is not enabled.
+func (l *Logger) logChange(msg string, fields ...zap.Field) {
+	core := l.Logger.Core()
+	if lc, ok := core.(*levelCore); ok {
+		core = lc.Core
+	}
+	ent := zapcore.Entry{
+		Level:      zapcore.InfoLevel,
+		Time:       l.Now(),
+		LoggerName: l.Logger.Name(),
+		Message:    msg,
+	}
+	if ce := core.Check(ent, nil); ce != nil {
+		ce.Write(fields...)
+	}
+}
+
+func levelsEqual(a, b map[string]Level) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || v != w {
+			return false
+		}
+	}
+	return true
+}
+
+// Watcher polls a JSON configuration file, in the same format accepted by
+// WithConfig, and applies the level, level overrides and sampling in it to a
+// logger when the file changes. Invalid configurations are logged and
+// ignored, keeping the current configuration.
+type Watcher struct {
+	mu       sync.Mutex
+	logger   *Logger
+	path     string
+	interval time.Duration
+	last     []byte
+	lastErr  string
+}
+
+// NewWatcher creates a Watcher that polls the file in the given path at the
+// given interval. The interval defaults to 10s.
+func NewWatcher(logger *Logger, path string, interval time.Duration) *Watcher {
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	return &Watcher{
+		logger:   logger,
+		path:     path,
+		interval: interval,
+	}
+}
+
+// Reload reads the configuration file and applies it if its content has
+// changed since the last reload.
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	b, err := os.ReadFile(w.path)
+	if err != nil {
+		return errors.Wrapf(err, "error reading %s", w.path)
+	}
+	if w.last != nil && bytes.Equal(b, w.last) {
+		return nil
+	}
+	// Do not retry invalid configurations until the file changes.
+	w.last = b
+
+	c := DefaultConfig()
+	if err := json.Unmarshal(b, c); err != nil {
+		return errors.Wrapf(err, "error parsing %s", w.path)
+	}
+	if err := w.logger.ApplyConfig(c); err != nil {
+		return errors.Wrapf(err, "error applying %s", w.path)
+	}
+	return nil
+}
+
+// Run reloads the configuration file until the given context is done. Errors
+// are logged only once until they change.
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.reload()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Watcher) reload() {
+	var msg string
+	err := w.Reload()
+	if err != nil {
+		msg = err.Error()
+	}
+
+	w.mu.Lock()
+	changed := msg != w.lastErr
+	w.lastErr = msg
+	w.mu.Unlock()
+
+	if err != nil && changed {
+		w.logger.Error("error reloading logging configuration",
+			zap.String("path", w.path), zap.Error(err))
+	}
+}
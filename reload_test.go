@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWatcher_Reload(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New("test", WithOutput(&buf), WithErrorOutput(&buf), WithFormatJSON())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "logging.json")
+	write := func(s string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(s), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := NewWatcher(logger, path, 0)
+	if err := w.Reload(); err == nil {
+		t.Error("Watcher.Reload() error = nil, wantErr true")
+	}
+
+	write(`{"level":"debug","levels":{"grpc":"error"},"sampling":{"initial":10}}`)
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Watcher.Reload() error = %v", err)
+	}
+	if logger.Level() != DebugLevel || logger.Levels()["grpc"] != ErrorLevel || logger.Sampling().Initial != 10 {
+		t.Errorf("configuration not applied: %v %v %v", logger.Level(), logger.Levels(), logger.Sampling())
+	}
+	for _, s := range []string{"logging level changed", "logging level overrides changed", "logging sampling changed"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("output does not contain %q: %s", s, buf.String())
+		}
+	}
+
+	buf.Reset()
+	write(`{"level":"warn","sampling":{"initial":-1}}`)
+	if err := w.Reload(); err == nil {
+		t.Error("Watcher.Reload() error = nil, wantErr true")
+	}
+	if logger.Level() != DebugLevel || logger.Sampling() == nil || buf.Len() != 0 {
+		t.Errorf("invalid configuration applied: %v %v %s", logger.Level(), logger.Sampling(), buf.String())
+	}
+
+	write(`{"level":"info"}`)
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Watcher.Reload() error = %v", err)
+	}
+	if logger.Level() != InfoLevel || len(logger.Levels()) != 0 || logger.Sampling() != nil {
+		t.Errorf("configuration not applied: %v %v %v", logger.Level(), logger.Levels(), logger.Sampling())
+	}
+}
+
+func TestLogger_ApplyConfig(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New("test", WithSink("json", &buf, AllLevels(), "json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := DefaultConfig()
+	c.Level = ErrorLevel
+	c.Levels = map[string]Level{"grpc": WarnLevel}
+	c.Sampling = &Sampling{SamplingPolicy: SamplingPolicy{Initial: 10}}
+	if err := logger.ApplyConfig(c); err != nil {
+		t.Fatalf("Logger.ApplyConfig() error = %v", err)
+	}
+	if logger.Level() != ErrorLevel || logger.Levels()["grpc"] != WarnLevel || logger.Sampling().Initial != 10 {
+		t.Errorf("configuration not applied: %v %v %v", logger.Level(), logger.Levels(), logger.Sampling())
+	}
+
+	// The changes are written even if info is disabled.
+	s := buf.String()
+	for _, msg := range []string{"logging level changed", "logging level overrides changed", "logging sampling changed"} {
+		if !strings.Contains(s, msg) {
+			t.Errorf("output does not contain %q: %s", msg, s)
+		}
+	}
+	if !strings.Contains(s, `"from":"info","to":"error"`) {
+		t.Errorf("output does not contain the level change: %s", s)
+	}
+
+	buf.Reset()
+	logger.Info("info message")
+	if buf.Len() != 0 {
+		t.Errorf("info message was written: %s", buf.String())
+	}
+}
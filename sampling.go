@@ -0,0 +1,175 @@
+package logging
+
+import (
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// countersSize is the number of counters used by a sampler, entries are
+// assigned to a counter using a hash of the level and message.
+const countersSize = 4096
+
+// defaultSamplingInterval is the interval used if none is configured.
+const defaultSamplingInterval = time.Second
+
+// SamplingPolicy defines how many entries with the same level and message are
+// logged in an interval. The first Initial entries are logged, and after that
+// only every Thereafter entry is logged. If Thereafter is 0, all the entries
+// after the initial ones are dropped.
+type SamplingPolicy struct {
+	Initial    int `json:"initial"`
+	Thereafter int `json:"thereafter"`
+}
+
+// Sampling configures the sampling of entries. Entries are grouped by level and
+// message, and each sink keeps its own counters. Entries with error or higher
+// levels are never sampled unless SampleErrors is set.
+type Sampling struct {
+	SamplingPolicy
+	// Interval is the period after which the counters are reset. Defaults to
+	// 1s.
+	Interval Duration `json:"interval"`
+	// Levels overrides the policy for some levels.
+	Levels map[Level]SamplingPolicy `json:"levels"`
+	// SampleErrors enables the sampling of error and higher levels.
+	SampleErrors bool `json:"sampleErrors"`
+}
+
+// Validate validates the sampling configuration.
+func (s *Sampling) Validate() error {
+	v := new(validator)
+	s.validate(v, "sampling")
+	return v.err()
+}
+
+func (s *Sampling) validate(v *validator, path string) {
+	if s == nil {
+		return
+	}
+	if s.Interval.Duration < 0 {
+		v.add(path+".interval", "cannot be negative")
+	}
+	s.SamplingPolicy.validate(v, path)
+	for l, p := range s.Levels {
+		p.validate(v, path+".levels."+l.String())
+	}
+}
+
+func (p SamplingPolicy) validate(v *validator, path string) {
+	if p.Initial < 0 {
+		v.add(path+".initial", "cannot be negative")
+	}
+	if p.Thereafter < 0 {
+		v.add(path+".thereafter", "cannot be negative")
+	}
+}
+
+// policy returns the policy for the given level.
+func (s *Sampling) policy(level Level) SamplingPolicy {
+	if p, ok := s.Levels[level]; ok {
+		return p
+	}
+	return s.SamplingPolicy
+}
+
+func (s *Sampling) interval() time.Duration {
+	if s.Interval.Duration == 0 {
+		return defaultSamplingInterval
+	}
+	return s.Interval.Duration
+}
+
+type counter struct {
+	resetAt atomic.Int64
+	count   atomic.Uint64
+}
+
+// inc increments the counter and returns the new value, resetting the counter
+// if the interval has elapsed.
+func (c *counter) inc(t time.Time, interval time.Duration) uint64 {
+	tn := t.UnixNano()
+	resetAt := c.resetAt.Load()
+	if resetAt > tn {
+		return c.count.Add(1)
+	}
+
+	c.count.Store(1)
+	if !c.resetAt.CompareAndSwap(resetAt, tn+interval.Nanoseconds()) {
+		// Another goroutine has reset the counter.
+		return c.count.Add(1)
+	}
+	return 1
+}
+
+// sampler keeps the counters of a sink and the number of entries dropped.
+type sampler struct {
+	levels   *levelSet
+	counters [countersSize]counter
+	sampled  atomic.Uint64
+}
+
+// newSampler returns a sampler that uses the sampling policy of the given
+// level set, so it can be changed at runtime.
+func newSampler(levels *levelSet) *sampler {
+	return &sampler{levels: levels}
+}
+
+// sample returns true if the entry must be logged.
+func (s *sampler) sample(ent zapcore.Entry) bool {
+	cfg := s.levels.getSampling()
+	if cfg == nil {
+		return true
+	}
+	level := Level(ent.Level)
+	if level >= ErrorLevel && !cfg.SampleErrors {
+		return true
+	}
+
+	p := cfg.policy(level)
+	n := s.counters[counterIndex(ent)].inc(ent.Time, cfg.interval())
+	if n <= uint64(p.Initial) || (p.Thereafter > 0 && (n-uint64(p.Initial))%uint64(p.Thereafter) == 0) {
+		return true
+	}
+
+	s.sampled.Add(1)
+	return false
+}
+
+// counterIndex returns the index of the counter for the entry using the
+// FNV-1a hash of the level and the message.
+func counterIndex(ent zapcore.Entry) uint32 {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	h := uint32(offset32)
+	h = (h ^ uint32(byte(ent.Level))) * prime32
+	for i := 0; i < len(ent.Message); i++ {
+		h = (h ^ uint32(ent.Message[i])) * prime32
+	}
+	return h % countersSize
+}
+
+// samplerCore is a zapcore.Core that samples the entries written to a sink.
+type samplerCore struct {
+	zapcore.Core
+	sampler *sampler
+}
+
+// With adds structured context to the core.
+func (c *samplerCore) With(fields []zapcore.Field) zapcore.Core {
+	return &samplerCore{
+		Core:    c.Core.With(fields),
+		sampler: c.sampler,
+	}
+}
+
+// Check determines whether the supplied Entry should be logged.
+func (c *samplerCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Core.Enabled(ent.Level) || !c.sampler.sample(ent) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLogger_sampling(t *testing.T) {
+	var out, errOut bytes.Buffer
+	logger, err := New("test", WithOutput(&out), WithErrorOutput(&errOut),
+		WithConfig([]byte(`{"sampling":{"initial":2,"thereafter":3,"interval":"1h","levels":{"warn":{"initial":1}}}}`)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		logger.Info("info message")
+		logger.Warn("warn message")
+		logger.Error("error message")
+	}
+
+	// 1, 2, 5, 8
+	if n := strings.Count(out.String(), "info message"); n != 4 {
+		t.Errorf("info entries = %d, want 4", n)
+	}
+	if n := strings.Count(errOut.String(), "warn message"); n != 1 {
+		t.Errorf("warn entries = %d, want 1", n)
+	}
+	if n := strings.Count(errOut.String(), "error message"); n != 10 {
+		t.Errorf("error entries = %d, want 10", n)
+	}
+
+	want := []SinkStats{{Name: "stdout", Sampled: 6}, {Name: "stderr", Sampled: 9}}
+	for i, s := range logger.SinkStats() {
+		if s != want[i] {
+			t.Errorf("Logger.SinkStats()[%d] = %+v, want %+v", i, s, want[i])
+		}
+	}
+
+	if err := logger.SetSampling(nil); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	for i := 0; i < 10; i++ {
+		logger.Info("info message")
+	}
+	if n := strings.Count(out.String(), "info message"); n != 10 {
+		t.Errorf("info entries = %d, want 10", n)
+	}
+
+	if err := logger.SetSampling(&Sampling{SamplingPolicy: SamplingPolicy{Initial: -1}}); err == nil {
+		t.Error("Logger.SetSampling() error = nil, wantErr true")
+	}
+}
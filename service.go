@@ -0,0 +1,129 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+// kubernetesEnv are the environment variables set using the Kubernetes
+// downward API and the keys of the fields added for them.
+var kubernetesEnv = []struct {
+	env, key string
+}{
+	{"POD_NAME", "k8s.pod"},
+	{"POD_NAMESPACE", "k8s.namespace"},
+	{"NODE_NAME", "k8s.node"},
+}
+
+// serviceKeys are the keys of the fields added by the ServiceInfo.
+var serviceKeys = []string{
+	"service", "version", "environment", "hostname", "pid",
+	"k8s.pod", "k8s.namespace", "k8s.node",
+}
+
+// ServiceInfo describes the service writing the entries. If it's configured,
+// every entry includes the fields "service", "version", "environment",
+// "hostname" and "pid", and the fields "k8s.pod", "k8s.namespace" and
+// "k8s.node" if the environment variables POD_NAME, POD_NAMESPACE and
+// NODE_NAME are set.
+type ServiceInfo struct {
+	// Name is the name of the service. Defaults to the name of the program.
+	Name string `json:"name"`
+	// Version is the version of the service. Defaults to the version of the
+	// main module or its VCS revision.
+	Version string `json:"version"`
+	// Environment is the environment of the service, for example
+	// "production".
+	Environment string `json:"environment"`
+	// Hostname is the name of the host. Defaults to the hostname of the
+	// machine.
+	Hostname string `json:"hostname"`
+}
+
+// WithServiceInfo adds the fields describing the service to every entry.
+func WithServiceInfo(s *ServiceInfo) Option {
+	return func(o *options) error {
+		o.Service = s
+		return nil
+	}
+}
+
+// WithFields adds the given fields to every entry.
+func WithFields(fields ...zap.Field) Option {
+	return func(o *options) error {
+		o.fields = append(o.fields, fields...)
+		return nil
+	}
+}
+
+// fields returns the fields describing the service, detecting the values not
+// configured.
+func (s *ServiceInfo) fields() []zap.Field {
+	if s == nil {
+		return nil
+	}
+
+	info := *s
+	if info.Name == "" {
+		info.Name = filepath.Base(os.Args[0])
+	}
+	if info.Version == "" {
+		info.Version = buildVersion()
+	}
+	if info.Hostname == "" {
+		info.Hostname, _ = os.Hostname()
+	}
+
+	fields := []zap.Field{
+		zap.String("service", info.Name),
+		zap.String("version", info.Version),
+		zap.String("environment", info.Environment),
+		zap.String("hostname", info.Hostname),
+		zap.Int("pid", os.Getpid()),
+	}
+	for _, e := range kubernetesEnv {
+		if v := os.Getenv(e.env); v != "" {
+			fields = append(fields, zap.String(e.key, v))
+		}
+	}
+	return fields
+}
+
+// buildVersion returns the version of the main module, or the VCS revision if
+// the version is not known.
+func buildVersion() string {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	if v := bi.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+	for _, s := range bi.Settings {
+		if s.Key == "vcs.revision" {
+			return s.Value
+		}
+	}
+	return ""
+}
+
+// staticFields returns the fields added to every entry: the service fields,
+// the configured fields sorted by key, and the fields added with WithFields.
+func (o *options) staticFields() []zap.Field {
+	fields := o.Service.fields()
+
+	keys := make([]string, 0, len(o.Fields))
+	for k := range o.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fields = append(fields, zap.String(k, o.Fields[k]))
+	}
+
+	return append(fields, o.fields...)
+}
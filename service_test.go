@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestLogger_staticFields(t *testing.T) {
+	t.Setenv("POD_NAME", "pod-1")
+	t.Setenv("POD_NAMESPACE", "")
+
+	var out bytes.Buffer
+	logger, err := New("test",
+		WithSink("out", &out, AllLevels(), "json"),
+		WithConfig(json.RawMessage(`{"service":{"name":"ca","environment":"production"},"fields":{"team":"pki"}}`)),
+		WithFields(zap.Int("shard", 3)),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	logger.Info("info message")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	hostname, _ := os.Hostname()
+	want := map[string]interface{}{
+		"service":     "ca",
+		"environment": "production",
+		"hostname":    hostname,
+		"pid":         float64(os.Getpid()),
+		"k8s.pod":     "pod-1",
+		"team":        "pki",
+		"shard":       float64(3),
+	}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("entry[%q] = %v, want %v", k, entry[k], v)
+		}
+	}
+	if _, ok := entry["version"]; !ok {
+		t.Error("entry does not have a version")
+	}
+	if _, ok := entry["k8s.namespace"]; ok {
+		t.Error("entry has an empty k8s.namespace")
+	}
+}
+
+func TestLogger_staticFieldsFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"text", "team\x1b[0m=pki"},
+		{"common", ` team="pki"` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var out bytes.Buffer
+			logger, err := New("test",
+				WithSink("out", &out, AllLevels(), tt.format),
+				WithFields(zap.String("team", "pki")),
+			)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			logger.Info("", zap.String("system", "http"), zap.String("method", "GET"), zap.String("referer", "ignored"))
+			if s := out.String(); !strings.Contains(s, tt.want) || strings.Contains(s, `referer="ignored"`) {
+				t.Errorf("output %q does not contain %q", s, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,381 @@
+package logging
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/smallstep/logging/encoder"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// minLevel and maxLevel are the bounds used by unbounded level ranges.
+	minLevel Level = math.MinInt8
+	maxLevel Level = math.MaxInt8
+)
+
+// LevelRange is an inclusive range of log levels. The zero value includes all
+// the levels, use LevelsBetween(InfoLevel, InfoLevel) for a range with only
+// the info level.
+type LevelRange struct {
+	Min Level `json:"min"`
+	Max Level `json:"max"`
+	// set distinguishes the ranges created with the functions of this
+	// package or decoded from JSON from the zero value.
+	set bool
+}
+
+// AllLevels returns a LevelRange that includes all the levels.
+func AllLevels() LevelRange {
+	return LevelRange{Min: minLevel, Max: maxLevel, set: true}
+}
+
+// LevelsFrom returns a LevelRange that includes the given level and all the
+// levels above it.
+func LevelsFrom(level Level) LevelRange {
+	return LevelRange{Min: level, Max: maxLevel, set: true}
+}
+
+// LevelsUpTo returns a LevelRange that includes the given level and all the
+// levels below it.
+func LevelsUpTo(level Level) LevelRange {
+	return LevelRange{Min: minLevel, Max: level, set: true}
+}
+
+// LevelsBetween returns a LevelRange that includes all the levels between min
+// and max, both included.
+func LevelsBetween(min, max Level) LevelRange {
+	return LevelRange{Min: min, Max: max, set: true}
+}
+
+// Enabled returns true if the given level is in the range. The zero value
+// enables all the levels.
+func (r LevelRange) Enabled(level Level) bool {
+	if r == (LevelRange{}) {
+		return true
+	}
+	return level >= r.Min && level <= r.Max
+}
+
+// UnmarshalJSON implements [json.Unmarshaler] for LevelRange. A bound not
+// present in the JSON is considered unbounded.
+func (r *LevelRange) UnmarshalJSON(data []byte) error {
+	type levelRange LevelRange
+	v := levelRange(AllLevels())
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*r = LevelRange(v)
+	return nil
+}
+
+// Sink defines a destination for the log entries. A logger can fan out to
+// multiple sinks, each one with its own output, format and range of levels.
+type Sink struct {
+	// Name identifies the sink. Defaults to the output.
+	Name string `json:"name"`
+	// Output is the destination of the entries. It can be "stdout", "stderr",
+	// the path of a file, the URL of a syslog server like "udp://host:514",
+	// "tcp://host:601" or "tls://host:6514", or "journald". It is ignored if
+	// Writer is set.
+	Output string `json:"output"`
+	// Rotation enables the rotation of the file used as output.
+	Rotation *Rotation `json:"rotation"`
+	// Syslog configures the messages and connection of syslog outputs.
+	Syslog *Syslog `json:"syslog"`
+	// Journald configures the journald output.
+	Journald *Journald `json:"journald"`
+	// Format is the format used to encode the entries. Defaults to the format
+	// of the logger, "syslog" for syslog outputs, or "journald" for the
+	// journald output.
+	Format string `json:"format"`
+	// Levels is the range of levels written to the sink. The level of the
+	// logger still applies. Defaults to all levels.
+	Levels LevelRange `json:"levels"`
+	// Writer is the destination of the entries if set.
+	Writer io.Writer `json:"-"`
+	// Core is used to write the entries instead of the output and format if
+	// set. The levels of the sink still apply.
+	Core zapcore.Core `json:"-"`
+}
+
+// UnmarshalJSON implements [json.Unmarshaler] for Sink. A sink without levels
+// will write all the levels.
+func (s *Sink) UnmarshalJSON(data []byte) error {
+	type sink Sink
+	v := sink{Levels: AllLevels()}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*s = Sink(v)
+	return nil
+}
+
+// name returns the name of the sink, or the output if it's not defined.
+func (s *Sink) name() string {
+	if s.Name == "" {
+		return s.Output
+	}
+	return s.Name
+}
+
+func (s *Sink) validate(v *validator, path string) {
+	if s.Writer == nil && s.Core == nil && s.Output == "" {
+		v.add(path+".output", "sink '%s' does not define an output", s.name())
+	}
+	if s.Format != "" {
+		if _, err := newEncoder(s.Format, zap.NewProductionEncoderConfig()); err != nil {
+			v.add(path+".format", "unsupported format '%s'", s.Format)
+		}
+	}
+	if s.isSyslog() {
+		validateSyslogOutput(v, path+".output", s.Output)
+		if s.Format != "" && !strings.EqualFold(s.Format, "syslog") {
+			v.add(path+".format", "sink '%s' requires the syslog format", s.name())
+		}
+	}
+	s.Syslog.validate(v, path+".syslog", s.Output)
+	if s.isJournald() && s.Format != "" && !strings.EqualFold(s.Format, "journald") {
+		v.add(path+".format", "sink '%s' requires the journald format", s.name())
+	}
+	if s.Levels.Min > s.Levels.Max {
+		v.add(path+".levels", "min level %s is greater than max level %s", s.Levels.Min, s.Levels.Max)
+	}
+	if r := s.Rotation; r != nil {
+		if s.Writer != nil || s.Core != nil || s.isSyslog() || s.isJournald() || s.Output == "stdout" || s.Output == "stderr" {
+			v.add(path+".rotation", "sink '%s' cannot rotate a non-file output", s.name())
+		}
+		if r.MaxSize < 0 {
+			v.add(path+".rotation.maxSize", "cannot be negative")
+		}
+		if r.Interval.Duration < 0 {
+			v.add(path+".rotation.interval", "cannot be negative")
+		}
+		if r.MaxAge.Duration < 0 {
+			v.add(path+".rotation.maxAge", "cannot be negative")
+		}
+		if r.MaxBackups < 0 {
+			v.add(path+".rotation.maxBackups", "cannot be negative")
+		}
+	}
+}
+
+// isSyslog returns true if the sink writes to a syslog server.
+func (s *Sink) isSyslog() bool {
+	return s.Writer == nil && s.Core == nil && isSyslogOutput(s.Output)
+}
+
+// isJournald returns true if the sink writes to journald.
+func (s *Sink) isJournald() bool {
+	return s.Writer == nil && s.Core == nil && s.Output == "journald"
+}
+
+// open returns the writer for the sink and a function that closes it if the
+// writer has been opened by the sink.
+func (s *Sink) open() (zapcore.WriteSyncer, func() error, error) {
+	if s.Writer != nil {
+		return zapcore.Lock(zapcore.AddSync(s.Writer)), nil, nil
+	}
+
+	switch s.Output {
+	case "stdout":
+		return zapcore.Lock(os.Stdout), nil, nil
+	case "stderr":
+		return zapcore.Lock(os.Stderr), nil, nil
+	case "journald":
+		w, err := NewJournaldWriter(s.Journald.socket())
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "error opening logger sink '%s'", s.name())
+		}
+		return w, w.Close, nil
+	default:
+		if s.isSyslog() {
+			w, err := NewSyslogWriter(s.Output, s.Syslog)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "error opening logger sink '%s'", s.name())
+			}
+			return w, w.Close, nil
+		}
+		if s.Rotation != nil {
+			w, err := NewFileWriter(s.Output, *s.Rotation)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "error opening logger sink '%s'", s.name())
+			}
+			return zapcore.Lock(w), w.Close, nil
+		}
+		f, err := os.OpenFile(s.Output, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "error opening logger sink '%s'", s.name())
+		}
+		return zapcore.Lock(f), f.Close, nil
+	}
+}
+
+// SinkStats contains the statistics of a sink.
+type SinkStats struct {
+	// Name is the name of the sink.
+	Name string `json:"name"`
+	// Sampled is the number of entries dropped by the sampling policy.
+	Sampled uint64 `json:"sampled"`
+	// Dropped is the number of entries dropped because the queue of an
+	// asynchronous sink was full.
+	Dropped uint64 `json:"dropped"`
+}
+
+// sinkState keeps the runtime state of a sink.
+type sinkState struct {
+	name    string
+	sampler *sampler
+	async   *asyncWriter
+}
+
+func (s *sinkState) stats() SinkStats {
+	stats := SinkStats{
+		Name:    s.name,
+		Sampled: s.sampler.sampled.Load(),
+	}
+	if s.async != nil {
+		stats.Dropped = s.async.dropped.Load()
+	}
+	return stats
+}
+
+// defaultSinks returns the sinks used if none is configured, info and debug
+// are written to stdout, and warnings and errors to stderr.
+func defaultSinks(o *options) []*Sink {
+	return []*Sink{
+		{Name: "stdout", Output: "stdout", Writer: o.output, Levels: LevelsUpTo(InfoLevel)},
+		{Name: "stderr", Output: "stderr", Writer: o.errorOutput, Levels: LevelsFrom(WarnLevel)},
+	}
+}
+
+// newEncoder returns the encoder for the given format.
+func newEncoder(format string, config zapcore.EncoderConfig) (zapcore.Encoder, error) {
+	switch strings.ToLower(format) {
+	case "", "text", "docker":
+		return encoder.NewTextEncoder(config), nil
+	case "json", "k8s", "kubernetes":
+		return zapcore.NewJSONEncoder(config), nil
+	case "common":
+		return encoder.NewCLFEncoder(config), nil
+	case "syslog":
+		return newSyslogEncoder(nil, config), nil
+	case "journald":
+		return newJournaldEncoder(nil, "", config), nil
+	case "gcp":
+		return newGCPEncoder(nil, config), nil
+	case "ecs":
+		return encoder.NewECSEncoder(config, encoder.ECSConfig{}), nil
+	default:
+		return nil, errors.Errorf("unsupported logger.format '%s'", format)
+	}
+}
+
+// newSinkCore creates the core that writes to the given sink, and initializes
+// the sink state.
+func newSinkCore(name string, s *Sink, o *options, config zapcore.EncoderConfig, state *sinkState, hooks *hookRunner) (zapcore.Core, func() error, error) {
+	if s.Core != nil {
+		return &rangeCore{Core: s.Core, levels: s.Levels}, nil, nil
+	}
+
+	format := s.Format
+	switch {
+	case format != "":
+	case s.isSyslog():
+		format = "syslog"
+	case s.isJournald():
+		format = "journald"
+	default:
+		format = o.Format
+	}
+
+	config = o.Encoding.formatConfig(format, config)
+
+	var enc zapcore.Encoder
+	switch strings.ToLower(format) {
+	case "syslog":
+		enc = newSyslogEncoder(s.Syslog, config)
+	case "journald":
+		enc = newJournaldEncoder(s.Journald, name, config)
+	case "gcp":
+		enc = newGCPEncoder(o.GCP, config)
+	case "ecs":
+		enc = encoder.NewECSEncoder(config, encoder.ECSConfig{ServiceName: name})
+	default:
+		var err error
+		if enc, err = newEncoder(format, config); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	out, closer, err := s.open()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if o.Async != nil {
+		w := newAsyncWriter(out, o.Async)
+		out, state.async = w, w
+		if fn := closer; fn != nil {
+			closer = func() error {
+				err := w.Close()
+				if e := fn(); e != nil && err == nil {
+					err = e
+				}
+				return err
+			}
+		} else {
+			closer = w.Close
+		}
+	}
+
+	// The level of the logger is checked by the levelCore.
+	levels := s.Levels
+	enabler := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+		return levels.Enabled(Level(lvl))
+	})
+
+	return &sinkCore{
+		LevelEnabler: enabler,
+		enc:          enc,
+		out:          out,
+		name:         name,
+		sink:         state.name,
+		hooks:        hooks,
+		errorDepth:   o.ErrorMaxDepth,
+	}, closer, nil
+}
+
+// rangeCore is a zapcore.Core that only writes the entries in a range of
+// levels.
+type rangeCore struct {
+	zapcore.Core
+	levels LevelRange
+}
+
+// Enabled returns true if the given level is in the range and it's enabled in
+// the underlying core.
+func (c *rangeCore) Enabled(lvl zapcore.Level) bool {
+	return c.levels.Enabled(Level(lvl)) && c.Core.Enabled(lvl)
+}
+
+// With adds structured context to the core.
+func (c *rangeCore) With(fields []zapcore.Field) zapcore.Core {
+	return &rangeCore{
+		Core:   c.Core.With(fields),
+		levels: c.levels,
+	}
+}
+
+// Check determines whether the supplied Entry should be logged.
+func (c *rangeCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.levels.Enabled(Level(ent.Level)) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
@@ -0,0 +1,139 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNew_sinks(t *testing.T) {
+	var out, errOut, all bytes.Buffer
+	logger, err := New("test",
+		WithLogLevel(DebugLevel),
+		WithSink("out", &out, LevelsUpTo(InfoLevel), "json"),
+		WithSink("err", &errOut, LevelsFrom(WarnLevel), "text"),
+		WithSink("all", &all, AllLevels(), ""),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	logger.Debug("debug message")
+	logger.Warn("warn message")
+
+	if s := out.String(); !strings.Contains(s, `"msg":"debug message"`) || strings.Contains(s, "warn message") {
+		t.Errorf("unexpected output %q", s)
+	}
+	if s := errOut.String(); !strings.Contains(s, "WARN\x1b[0m warn message") || strings.Contains(s, "debug message") {
+		t.Errorf("unexpected error output %q", s)
+	}
+	if s := all.String(); strings.Count(s, "\n") != 2 {
+		t.Errorf("unexpected output %q", s)
+	}
+}
+
+func TestNew_sinkZeroLevels(t *testing.T) {
+	var zero, info bytes.Buffer
+	logger, err := New("test",
+		WithLogLevel(DebugLevel),
+		WithSink("zero", &zero, LevelRange{}, "json"),
+		WithSink("info", &info, LevelsBetween(InfoLevel, InfoLevel), "json"),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	logger.Debug("debug message")
+	logger.Info("info message")
+	logger.Error("error message")
+
+	if s := zero.String(); strings.Count(s, "\n") != 3 {
+		t.Errorf("unexpected output %q", s)
+	}
+	if s := info.String(); strings.Count(s, "\n") != 1 || !strings.Contains(s, "info message") {
+		t.Errorf("unexpected info output %q", s)
+	}
+
+	var r LevelRange
+	if err := json.Unmarshal([]byte(`{"min":"info","max":"info"}`), &r); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if r.Enabled(DebugLevel) || !r.Enabled(InfoLevel) || r.Enabled(WarnLevel) {
+		t.Errorf("LevelRange %v does not only enable the info level", r)
+	}
+}
+
+func TestNew_outputs(t *testing.T) {
+	var out, errOut bytes.Buffer
+	logger, err := New("test", WithOutput(&out), WithErrorOutput(&errOut))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	logger.Debug("debug message")
+	logger.Info("info message")
+	logger.Error("error message")
+
+	if s := out.String(); strings.Count(s, "\n") != 1 || !strings.Contains(s, "info message") {
+		t.Errorf("unexpected output %q", s)
+	}
+	if s := errOut.String(); strings.Count(s, "\n") != 1 || !strings.Contains(s, "error message") {
+		t.Errorf("unexpected error output %q", s)
+	}
+}
+
+func TestWithConfig_sinks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "errors.log")
+	raw, err := json.Marshal(map[string]any{
+		"format": "json",
+		"sinks": []map[string]any{
+			{"name": "file", "output": path, "levels": map[string]any{"min": "error"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger, err := New("test", WithConfig(raw))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	logger.Info("info message")
+	logger.Error("error message")
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Logger.Close() error = %v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(b); strings.Count(s, "\n") != 1 || !strings.Contains(s, `"msg":"error message"`) {
+		t.Errorf("unexpected file content %q", s)
+	}
+}
+
+func TestNew_sinkErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"no output", []Option{WithConfig([]byte(`{"sinks":[{"name":"foo"}]}`))}},
+		{"bad format", []Option{WithConfig([]byte(`{"sinks":[{"output":"stdout","format":"foo"}]}`))}},
+		{"bad levels", []Option{WithSink("foo", &bytes.Buffer{}, LevelsBetween(ErrorLevel, InfoLevel), "")}},
+		{"duplicated", []Option{
+			WithSink("foo", &bytes.Buffer{}, AllLevels(), ""),
+			WithSink("foo", &bytes.Buffer{}, AllLevels(), ""),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New("test", tt.opts...); err == nil {
+				t.Error("New() error = nil, wantErr true")
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SlogHandler is a [slog.Handler] that writes the records using a Logger, so
+// they are encoded in the same format as the rest of the entries.
+type SlogHandler struct {
+	logger *Logger
+	groups []slogGroup
+}
+
+// slogGroup is a group opened with WithGroup and the attributes added to it.
+type slogGroup struct {
+	name  string
+	attrs []slog.Attr
+}
+
+// NewSlogHandler returns a [slog.Handler] backed by the given logger. The
+// request-id, tracing-id and span fields in the context are added to each
+// record. The caller and the stack traces are added to the records if they are
+// enabled in the logger with WithCaller and WithStacktrace.
+func NewSlogHandler(logger *Logger) *SlogHandler {
+	return &SlogHandler{logger: logger}
+}
+
+// SlogHandler returns a [slog.Handler] backed by the logger.
+func (l *Logger) SlogHandler() slog.Handler {
+	return NewSlogHandler(l)
+}
+
+// Enabled implements [slog.Handler].
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.Core().Enabled(zapcore.Level(fromSlogLevel(level)))
+}
+
+// Handle implements [slog.Handler].
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	ent := zapcore.Entry{
+		Level:      zapcore.Level(fromSlogLevel(r.Level)),
+		Time:       r.Time,
+		LoggerName: h.logger.Logger.Name(),
+		Message:    r.Message,
+	}
+	// Records created without a time use the clock of the logger.
+	if ent.Time.IsZero() {
+		ent.Time = h.logger.Now()
+	}
+	// The caller and the stack trace use the options of the logger, like the
+	// entries written with it.
+	o := h.logger.options
+	if o.Caller != nil && r.PC != 0 {
+		frames := runtime.CallersFrames([]uintptr{r.PC})
+		f, _ := frames.Next()
+		ent.Caller = zapcore.NewEntryCaller(f.PC, f.File, f.Line, true)
+		ent.Caller.Function = f.Function
+	}
+	if o.StacktraceLevel != nil && Level(ent.Level) >= *o.StacktraceLevel {
+		ent.Stack = slogStack(r.PC)
+	}
+
+	ce := h.logger.Core().Check(ent, nil)
+	if ce == nil {
+		return nil
+	}
+
+	fields := make([]zap.Field, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendSlogAttr(fields, a)
+		return true
+	})
+	// Nest the fields in the open groups, from the innermost to the outermost.
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		g := h.groups[i]
+		groupFields := make([]zap.Field, 0, len(g.attrs)+len(fields))
+		for _, a := range g.attrs {
+			groupFields = appendSlogAttr(groupFields, a)
+		}
+		groupFields = append(groupFields, fields...)
+		if len(groupFields) == 0 {
+			fields = nil
+		} else {
+			fields = []zap.Field{zap.Object(g.name, zapFields(groupFields))}
+		}
+	}
+
+	ce.Write(append(h.logger.contextFields(ctx), fields...)...)
+	return nil
+}
+
+// slogStack returns the stack trace starting at the frame of the given program
+// counter, the caller of the slog function. If the frame is not found, the
+// stack trace starts at the caller of Handle.
+func slogStack(pc uintptr) string {
+	var caller runtime.Frame
+	if pc != 0 {
+		caller, _ = runtime.CallersFrames([]uintptr{pc}).Next()
+	}
+
+	pcs := make([]uintptr, 64)
+	pcs = pcs[:runtime.Callers(3, pcs)]
+	var found bool
+	var frames []runtime.Frame
+	it := runtime.CallersFrames(pcs)
+	for {
+		f, more := it.Next()
+		if !found && f.Function == caller.Function && f.File == caller.File && f.Line == caller.Line {
+			found, frames = true, frames[:0]
+		}
+		frames = append(frames, f)
+		if !more {
+			break
+		}
+	}
+
+	var sb strings.Builder
+	for i, f := range frames {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(f.Function + "\n\t" + f.File + ":" + strconv.Itoa(f.Line))
+	}
+	return sb.String()
+}
+
+// WithAttrs implements [slog.Handler].
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	// Without groups the attributes are added to the logger.
+	if len(h.groups) == 0 {
+		fields := make([]zap.Field, 0, len(attrs))
+		for _, a := range attrs {
+			fields = appendSlogAttr(fields, a)
+		}
+		return &SlogHandler{
+			logger: h.logger.Clone(zap.Fields(fields...)),
+		}
+	}
+
+	groups := make([]slogGroup, len(h.groups))
+	copy(groups, h.groups)
+	last := &groups[len(groups)-1]
+	last.attrs = append(last.attrs[:len(last.attrs):len(last.attrs)], attrs...)
+	return &SlogHandler{
+		logger: h.logger,
+		groups: groups,
+	}
+}
+
+// WithGroup implements [slog.Handler].
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	groups := make([]slogGroup, len(h.groups), len(h.groups)+1)
+	copy(groups, h.groups)
+	return &SlogHandler{
+		logger: h.logger,
+		groups: append(groups, slogGroup{name: name}),
+	}
+}
+
+// fromSlogLevel maps a slog.Level to the closest Level.
+func fromSlogLevel(l slog.Level) Level {
+	switch {
+	case l < slog.LevelDebug:
+		return TraceLevel
+	case l < slog.LevelInfo:
+		return DebugLevel
+	case l < slog.LevelWarn:
+		return InfoLevel
+	case l < slog.LevelError:
+		return WarnLevel
+	default:
+		return ErrorLevel
+	}
+}
+
+// appendSlogAttr converts the given attribute to a zap.Field and appends it to
+// fields. Empty attributes and groups are ignored, and groups without a key
+// are inlined.
+func appendSlogAttr(fields []zap.Field, a slog.Attr) []zap.Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return append(fields, zap.String(a.Key, a.Value.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(a.Key, a.Value.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(a.Key, a.Value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(a.Key, a.Value.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(a.Key, a.Value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(a.Key, a.Value.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(a.Key, a.Value.Time()))
+	case slog.KindGroup:
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return fields
+		}
+		if a.Key == "" {
+			for _, ga := range attrs {
+				fields = appendSlogAttr(fields, ga)
+			}
+			return fields
+		}
+		group := make([]zap.Field, 0, len(attrs))
+		for _, ga := range attrs {
+			group = appendSlogAttr(group, ga)
+		}
+		return append(fields, zap.Object(a.Key, zapFields(group)))
+	default:
+		if err, ok := a.Value.Any().(error); ok {
+			return append(fields, zap.NamedError(a.Key, err))
+		}
+		return append(fields, zap.Any(a.Key, a.Value.Any()))
+	}
+}
+
+// zapFields implements zapcore.ObjectMarshaler for a list of fields.
+type zapFields []zap.Field
+
+// MarshalLogObject adds the fields to the encoder.
+func (fs zapFields) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for i := range fs {
+		fs[i].AddTo(enc)
+	}
+	return nil
+}
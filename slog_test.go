@@ -0,0 +1,178 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smallstep/logging/internal/testclock"
+)
+
+type slogUser struct {
+	name string
+	id   int
+}
+
+func (u slogUser) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("name", u.name), slog.Int("id", u.id))
+}
+
+func newSlogTestLogger(t *testing.T, opts ...Option) (*slog.Logger, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	logger, err := New("test", append([]Option{
+		WithSink("json", &buf, AllLevels(), "json"),
+		WithLogLevel(TraceLevel),
+	}, opts...)...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return slog.New(logger.SlogHandler()), &buf
+}
+
+func decodeEntry(t *testing.T, b []byte) map[string]interface{} {
+	t.Helper()
+	var entry map[string]interface{}
+	if err := json.Unmarshal(b, &entry); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	return entry
+}
+
+func TestSlogHandler_groups(t *testing.T) {
+	logger, buf := newSlogTestLogger(t)
+	logger.With("service", "api").
+		WithGroup("a").With("x", 1).
+		WithGroup("b").
+		Info("grouped", "y", 2, slog.Group("c", "z", 3), slog.Group("", "inline", true), slog.Group("empty"))
+
+	entry := decodeEntry(t, buf.Bytes())
+	if entry["service"] != "api" {
+		t.Errorf("service = %v, want api", entry["service"])
+	}
+	want := map[string]interface{}{
+		"x": float64(1),
+		"b": map[string]interface{}{
+			"y":      float64(2),
+			"c":      map[string]interface{}{"z": float64(3)},
+			"inline": true,
+		},
+	}
+	if !reflect.DeepEqual(entry["a"], want) {
+		t.Errorf("a = %v, want %v", entry["a"], want)
+	}
+
+	// Groups without attributes are omitted.
+	buf.Reset()
+	logger.WithGroup("a").WithGroup("b").Info("no attrs")
+	if entry := decodeEntry(t, buf.Bytes()); entry["a"] != nil {
+		t.Errorf("a = %v, want nil", entry["a"])
+	}
+}
+
+func TestSlogHandler_logValuer(t *testing.T) {
+	logger, buf := newSlogTestLogger(t)
+	logger.With("owner", slogUser{"alice", 1}).Info("valuer", "user", slogUser{"bob", 2})
+
+	entry := decodeEntry(t, buf.Bytes())
+	if want := map[string]interface{}{"name": "alice", "id": float64(1)}; !reflect.DeepEqual(entry["owner"], want) {
+		t.Errorf("owner = %v, want %v", entry["owner"], want)
+	}
+	if want := map[string]interface{}{"name": "bob", "id": float64(2)}; !reflect.DeepEqual(entry["user"], want) {
+		t.Errorf("user = %v, want %v", entry["user"], want)
+	}
+}
+
+func TestSlogHandler_source(t *testing.T) {
+	logger, buf := newSlogTestLogger(t, WithCaller(&Caller{Function: true}))
+	logger.Info("source")
+
+	entry := decodeEntry(t, buf.Bytes())
+	if s, _ := entry["caller"].(string); !strings.Contains(s, "/slog_test.go:") {
+		t.Errorf("caller = %v, want dir/slog_test.go:line", entry["caller"])
+	}
+	if entry["function"] != "github.com/smallstep/logging.TestSlogHandler_source" {
+		t.Errorf("function = %v", entry["function"])
+	}
+}
+
+func TestSlogHandler_noSource(t *testing.T) {
+	logger, buf := newSlogTestLogger(t)
+	logger.Info("source")
+
+	entry := decodeEntry(t, buf.Bytes())
+	if v, ok := entry["caller"]; ok {
+		t.Errorf("caller = %v, want no caller", v)
+	}
+}
+
+func TestSlogHandler_stacktrace(t *testing.T) {
+	logger, buf := newSlogTestLogger(t, WithStacktrace(WarnLevel))
+	logger.Info("info")
+	logger.Warn("warn")
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("got %d entries, want 2", len(lines))
+	}
+	if v, ok := decodeEntry(t, lines[0])["stacktrace"]; ok {
+		t.Errorf("info stacktrace = %v, want no stacktrace", v)
+	}
+	s, _ := decodeEntry(t, lines[1])["stacktrace"].(string)
+	if !strings.HasPrefix(s, "github.com/smallstep/logging.TestSlogHandler_stacktrace\n") {
+		t.Errorf("warn stacktrace = %q, want it to start at the test", s)
+	}
+}
+
+func TestSlogHandler_levels(t *testing.T) {
+	tests := []struct {
+		level slog.Level
+		want  string
+	}{
+		{slog.LevelDebug - 4, "trace"},
+		{slog.LevelDebug - 1, "trace"},
+		{slog.LevelDebug, "debug"},
+		{slog.LevelInfo, "info"},
+		{slog.LevelWarn, "warn"},
+		{slog.LevelError, "error"},
+		{slog.LevelError + 4, "error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			logger, buf := newSlogTestLogger(t)
+			logger.Log(context.Background(), tt.level, "level")
+			if entry := decodeEntry(t, buf.Bytes()); entry["level"] != tt.want {
+				t.Errorf("level = %v, want %v", entry["level"], tt.want)
+			}
+		})
+	}
+
+	// Levels below the logger level are disabled.
+	logger, buf := newSlogTestLogger(t, WithLogLevel(InfoLevel))
+	logger.Log(context.Background(), slog.LevelDebug-4, "disabled")
+	if buf.Len() != 0 {
+		t.Errorf("disabled level was written: %s", buf.String())
+	}
+}
+
+func TestSlogHandler_zeroTime(t *testing.T) {
+	clock := testclock.New(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	var buf bytes.Buffer
+	logger, err := New("test", WithSink("json", &buf, AllLevels(), "json"), WithClock(clock))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := logger.SlogHandler().Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelInfo, "no time", 0)); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	entry := decodeEntry(t, buf.Bytes())
+	if want := float64(clock.Now().Unix()); entry["ts"] != want {
+		t.Errorf("ts = %v, want %v", entry["ts"], want)
+	}
+}
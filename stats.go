@@ -0,0 +1,181 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Stats is a hook that keeps the number of entries and bytes written by level,
+// logger name and sink, and reports them with the entries sampled and
+// dropped by the logger. It implements expvar.Var, so it can be published
+// using expvar.Publish, and its Handler writes the statistics in the
+// Prometheus text format.
+type Stats struct {
+	mu       sync.RWMutex
+	counters map[statsKey]*statsCounter
+	logger   atomic.Pointer[Logger]
+}
+
+type statsKey struct {
+	level Level
+	name  string
+	sink  string
+}
+
+type statsCounter struct {
+	entries atomic.Uint64
+	bytes   atomic.Uint64
+}
+
+// NewStats creates a new Stats, use WithStats to add it to a logger.
+func NewStats() *Stats {
+	return &Stats{
+		counters: make(map[statsKey]*statsCounter),
+	}
+}
+
+// WithStats adds the given stats hook to the logger.
+func WithStats(s *Stats) Option {
+	return func(o *options) error {
+		o.stats = s
+		return nil
+	}
+}
+
+// attach sets the logger used to get the sampled and dropped entries.
+func (s *Stats) attach(l *Logger) {
+	s.logger.Store(l)
+}
+
+func (s *Stats) hook(e HookEntry) {
+	key := statsKey{level: e.Level, name: e.Name, sink: e.Sink}
+	s.mu.RLock()
+	c, ok := s.counters[key]
+	s.mu.RUnlock()
+	if !ok {
+		s.mu.Lock()
+		if c, ok = s.counters[key]; !ok {
+			c = new(statsCounter)
+			s.counters[key] = c
+		}
+		s.mu.Unlock()
+	}
+	c.entries.Add(1)
+	c.bytes.Add(uint64(e.Size))
+}
+
+// EntryStats contains the number of entries and bytes written with a level
+// and logger name to a sink.
+type EntryStats struct {
+	Level   Level  `json:"level"`
+	Name    string `json:"name"`
+	Sink    string `json:"sink"`
+	Entries uint64 `json:"entries"`
+	Bytes   uint64 `json:"bytes"`
+}
+
+// StatsSnapshot contains the statistics of a logger at a given time.
+type StatsSnapshot struct {
+	Entries      []EntryStats `json:"entries"`
+	Sinks        []SinkStats  `json:"sinks"`
+	HooksDropped uint64       `json:"hooksDropped"`
+}
+
+// Snapshot returns the current statistics. The entries are sorted by sink,
+// name and level.
+func (s *Stats) Snapshot() StatsSnapshot {
+	s.mu.RLock()
+	entries := make([]EntryStats, 0, len(s.counters))
+	for k, c := range s.counters {
+		entries = append(entries, EntryStats{
+			Level:   k.level,
+			Name:    k.name,
+			Sink:    k.sink,
+			Entries: c.entries.Load(),
+			Bytes:   c.bytes.Load(),
+		})
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Sink != b.Sink {
+			return a.Sink < b.Sink
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Level < b.Level
+	})
+
+	snapshot := StatsSnapshot{Entries: entries}
+	if l := s.logger.Load(); l != nil {
+		snapshot.Sinks = l.SinkStats()
+		snapshot.HooksDropped = l.HooksDropped()
+	}
+	return snapshot
+}
+
+// String implements expvar.Var, it returns the snapshot in JSON.
+func (s *Stats) String() string {
+	b, err := json.Marshal(s.Snapshot())
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
+}
+
+// Handler returns an http.Handler that writes the statistics in the
+// Prometheus text format.
+func (s *Stats) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = s.WritePrometheus(w)
+	})
+}
+
+// WritePrometheus writes the statistics in the Prometheus text format.
+func (s *Stats) WritePrometheus(w io.Writer) error {
+	snapshot := s.Snapshot()
+	var b strings.Builder
+
+	writeHeader := func(name, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	}
+
+	writeHeader("logging_entries_total", "Number of log entries written.")
+	for _, e := range snapshot.Entries {
+		fmt.Fprintf(&b, "logging_entries_total{level=%s,name=%s,sink=%s} %d\n",
+			promLabel(e.Level.String()), promLabel(e.Name), promLabel(e.Sink), e.Entries)
+	}
+	writeHeader("logging_bytes_total", "Number of bytes of the log entries written.")
+	for _, e := range snapshot.Entries {
+		fmt.Fprintf(&b, "logging_bytes_total{level=%s,name=%s,sink=%s} %d\n",
+			promLabel(e.Level.String()), promLabel(e.Name), promLabel(e.Sink), e.Bytes)
+	}
+	writeHeader("logging_sampled_total", "Number of log entries dropped by sampling.")
+	for _, e := range snapshot.Sinks {
+		fmt.Fprintf(&b, "logging_sampled_total{sink=%s} %d\n", promLabel(e.Name), e.Sampled)
+	}
+	writeHeader("logging_dropped_total", "Number of log entries dropped because the queue was full.")
+	for _, e := range snapshot.Sinks {
+		fmt.Fprintf(&b, "logging_dropped_total{sink=%s} %d\n", promLabel(e.Name), e.Dropped)
+	}
+	writeHeader("logging_hooks_dropped_total", "Number of log entries not sent to the hooks.")
+	fmt.Fprintf(&b, "logging_hooks_dropped_total %d\n", snapshot.HooksDropped)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// promLabel returns the quoted label value escaping backslashes, double
+// quotes and new lines.
+func promLabel(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
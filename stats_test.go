@@ -0,0 +1,102 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLogger_hooks(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		entries []HookEntry
+	)
+	var out bytes.Buffer
+	logger, err := New("test",
+		WithSink("out", &out, AllLevels(), "json"),
+		WithHook(func(e HookEntry) {
+			mu.Lock()
+			entries = append(entries, e)
+			mu.Unlock()
+		}),
+		WithHook(func(e HookEntry) {
+			panic("hooks cannot break the logger")
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	logger.Info("info message")
+	logger.Named("child").Error("error message")
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Logger.Close() error = %v", err)
+	}
+
+	lines := strings.SplitAfter(out.String(), "\n")
+	want := []HookEntry{
+		{Level: InfoLevel, Name: "test", Sink: "out", Size: len(lines[0])},
+		{Level: ErrorLevel, Name: "test.child", Sink: "out", Size: len(lines[1])},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d hook entries, want %d", len(entries), len(want))
+	}
+	for i, e := range entries {
+		e.Time = want[i].Time
+		if e != want[i] {
+			t.Errorf("hook entry %d = %+v, want %+v", i, e, want[i])
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	stats := NewStats()
+	logger, err := New("test",
+		WithSink("out", io.Discard, AllLevels(), "json"),
+		WithSampling(&Sampling{SamplingPolicy: SamplingPolicy{Initial: 1}}),
+		WithStats(stats),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	logger.Info("info message")
+	logger.Info("info message")
+	logger.Named("child").Warn("warn message")
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Logger.Close() error = %v", err)
+	}
+
+	var snapshot StatsSnapshot
+	if err := json.Unmarshal([]byte(stats.String()), &snapshot); err != nil {
+		t.Fatalf("Stats.String() is not valid JSON: %v", err)
+	}
+	if len(snapshot.Entries) != 2 || snapshot.Entries[0].Name != "test" || snapshot.Entries[0].Entries != 1 ||
+		snapshot.Entries[1].Name != "test.child" || snapshot.Entries[1].Level != WarnLevel {
+		t.Errorf("unexpected entries %+v", snapshot.Entries)
+	}
+	if len(snapshot.Sinks) != 1 || snapshot.Sinks[0].Sampled != 1 {
+		t.Errorf("unexpected sinks %+v", snapshot.Sinks)
+	}
+
+	rec := httptest.NewRecorder()
+	stats.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, s := range []string{
+		"# TYPE logging_entries_total counter\n",
+		`logging_entries_total{level="info",name="test",sink="out"} 1` + "\n",
+		`logging_entries_total{level="warn",name="test.child",sink="out"} 1` + "\n",
+		`logging_bytes_total{level="info",name="test",sink="out"} `,
+		`logging_sampled_total{sink="out"} 1` + "\n",
+		`logging_dropped_total{sink="out"} 0` + "\n",
+		"logging_hooks_dropped_total 0\n",
+	} {
+		if !strings.Contains(body, s) {
+			t.Errorf("metrics do not contain %q:\n%s", s, body)
+		}
+	}
+}
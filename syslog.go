@@ -0,0 +1,352 @@
+package logging
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/smallstep/logging/encoder"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// syslogDialTimeout is the maximum time to wait for a connection.
+	syslogDialTimeout = 5 * time.Second
+	// syslogWriteTimeout is the maximum time to wait for a write.
+	syslogWriteTimeout = 5 * time.Second
+	// syslogMinBackoff and syslogMaxBackoff are the bounds of the time waited
+	// between reconnection attempts.
+	syslogMinBackoff = 100 * time.Millisecond
+	syslogMaxBackoff = 30 * time.Second
+)
+
+// syslogFacilities are the facility codes defined in RFC 5424.
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5,
+	"lpr": 6, "news": 7, "uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"ntp": 12, "security": 13, "console": 14, "solaris-cron": 15,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// Syslog configures the syslog messages of a sink. A sink writes to a syslog
+// server if the output is an URL like "udp://host:514", "tcp://host:601" or
+// "tls://host:6514". Messages use the RFC 5424 format, and over TCP and TLS
+// they are framed using the RFC 6587 octet counting.
+type Syslog struct {
+	// Facility is the syslog facility, for example "daemon" or "local0".
+	// Defaults to "user".
+	Facility string `json:"facility"`
+	// Hostname is the HOSTNAME of the messages. Defaults to the hostname of
+	// the machine.
+	Hostname string `json:"hostname"`
+	// AppName is the APP-NAME of the messages. Defaults to the name of the
+	// program.
+	AppName string `json:"appName"`
+	// SDID is the SD-ID of the element with the fields of the entries.
+	// Defaults to "fields@32473".
+	SDID string `json:"sdID"`
+	// TLS configures the TLS connections.
+	TLS *SyslogTLS `json:"tls"`
+}
+
+// SyslogTLS configures the TLS connection to a syslog server.
+type SyslogTLS struct {
+	// CA is the path of the PEM file with the roots used to verify the
+	// server. Defaults to the system roots.
+	CA string `json:"ca"`
+	// Cert and Key are the paths of the PEM files with the client certificate
+	// and key.
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
+	// ServerName is the name used to verify the server. Defaults to the host
+	// in the output.
+	ServerName string `json:"serverName"`
+	// InsecureSkipVerify disables the verification of the server.
+	InsecureSkipVerify bool `json:"insecureSkipVerify"`
+	// Config is the TLS configuration used if set.
+	Config *tls.Config `json:"-"`
+}
+
+// isSyslogOutput returns true if the output is the URL of a syslog server.
+func isSyslogOutput(output string) bool {
+	switch {
+	case strings.HasPrefix(output, "udp://"),
+		strings.HasPrefix(output, "tcp://"),
+		strings.HasPrefix(output, "tls://"):
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *Syslog) validate(v *validator, path, output string) {
+	if s == nil {
+		return
+	}
+	if _, ok := syslogFacilities[s.Facility]; !ok && s.Facility != "" {
+		v.add(path+".facility", "unsupported facility '%s'", s.Facility)
+	}
+	if s.TLS != nil {
+		if !strings.HasPrefix(output, "tls://") {
+			v.add(path+".tls", "requires a tls:// output")
+		}
+		if (s.TLS.Cert == "") != (s.TLS.Key == "") {
+			v.add(path+".tls", "cert and key must be set together")
+		}
+	}
+}
+
+func validateSyslogOutput(v *validator, path, output string) {
+	u, err := url.Parse(output)
+	if err != nil {
+		v.add(path, "invalid syslog url '%s'", output)
+		return
+	}
+	if _, port, err := net.SplitHostPort(u.Host); err != nil || port == "" || u.Hostname() == "" {
+		v.add(path, "syslog url '%s' must include a host and a port", output)
+	}
+}
+
+// encoderConfig returns the values of the header of the messages.
+func (s *Syslog) encoderConfig() encoder.SyslogConfig {
+	var c Syslog
+	if s != nil {
+		c = *s
+	}
+
+	facility, ok := syslogFacilities[c.Facility]
+	if !ok {
+		facility = syslogFacilities["user"]
+	}
+	if c.Hostname == "" {
+		c.Hostname, _ = os.Hostname()
+	}
+	if c.AppName == "" {
+		c.AppName = filepath.Base(os.Args[0])
+	}
+	return encoder.SyslogConfig{
+		Facility: facility,
+		Hostname: c.Hostname,
+		AppName:  c.AppName,
+		ProcID:   strconv.Itoa(os.Getpid()),
+		SDID:     c.SDID,
+	}
+}
+
+// tlsConfig returns the TLS configuration used to connect to the server in the
+// given host.
+func (s *Syslog) tlsConfig(host string) (*tls.Config, error) {
+	var t SyslogTLS
+	if s != nil && s.TLS != nil {
+		t = *s.TLS
+	}
+	if t.Config != nil {
+		return t.Config, nil
+	}
+
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify, //nolint:gosec // explicitly configured
+	}
+	if config.ServerName == "" {
+		config.ServerName = host
+	}
+	if t.CA != "" {
+		b, err := os.ReadFile(t.CA)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading %s", t.CA)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, errors.Errorf("error parsing %s: no certificates found", t.CA)
+		}
+		config.RootCAs = pool
+	}
+	if t.Cert != "" {
+		cert, err := tls.LoadX509KeyPair(t.Cert, t.Key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error loading %s", t.Cert)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// SyslogWriter is a zapcore.WriteSyncer that sends each write as a message to
+// a syslog server. Over TCP and TLS the messages are framed using octet
+// counting. If the connection fails, it reconnects with an exponential
+// backoff, dropping the messages written while it's disconnected.
+type SyslogWriter struct {
+	mu        sync.Mutex
+	network   string
+	address   string
+	tlsConfig *tls.Config
+	framed    bool
+	conn      net.Conn
+	dialing   bool
+	backoff   time.Duration
+	nextDial  time.Time
+	closed    bool
+	now       func() time.Time
+}
+
+// NewSyslogWriter creates a SyslogWriter for the given URL, the scheme must be
+// "udp", "tcp" or "tls". The connection is established on the first write.
+func NewSyslogWriter(rawURL string, s *Syslog) (*SyslogWriter, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing %s", rawURL)
+	}
+
+	w := &SyslogWriter{
+		address: u.Host,
+		now:     time.Now,
+	}
+	switch u.Scheme {
+	case "udp":
+		w.network = "udp"
+	case "tcp":
+		w.network, w.framed = "tcp", true
+	case "tls":
+		w.network, w.framed = "tcp", true
+		if w.tlsConfig, err = s.tlsConfig(u.Hostname()); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.Errorf("unsupported syslog scheme '%s'", u.Scheme)
+	}
+	return w, nil
+}
+
+// Write sends p as a syslog message. A trailing new line is removed.
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, errors.New("syslog writer is closed")
+	}
+
+	msg := w.frame(bytes.TrimRight(p, "\n"))
+	// Retry once with a new connection, the server might have closed the
+	// previous one.
+	for i := 0; i < 2; i++ {
+		if err := w.connect(); err != nil {
+			return 0, err
+		}
+		if err := w.conn.SetWriteDeadline(time.Now().Add(syslogWriteTimeout)); err == nil {
+			if _, err = w.conn.Write(msg); err == nil {
+				return len(p), nil
+			}
+		}
+		w.disconnect()
+	}
+	return 0, errors.Errorf("error writing to syslog server %s", w.address)
+}
+
+func (w *SyslogWriter) frame(p []byte) []byte {
+	if !w.framed {
+		return p
+	}
+	msg := make([]byte, 0, len(p)+8)
+	msg = strconv.AppendInt(msg, int64(len(p)), 10)
+	msg = append(msg, ' ')
+	return append(msg, p...)
+}
+
+// connect dials the server if there is no connection and the backoff has
+// elapsed. It must be called with the lock held, the lock is released while
+// dialing so other writes fail fast instead of waiting for the dial.
+func (w *SyslogWriter) connect() error {
+	if w.conn != nil {
+		return nil
+	}
+	now := w.now()
+	if w.dialing || now.Before(w.nextDial) {
+		return errors.Errorf("error connecting to syslog server %s: waiting to reconnect", w.address)
+	}
+
+	w.dialing = true
+	w.mu.Unlock()
+	conn, err := w.dial()
+	w.mu.Lock()
+	w.dialing = false
+
+	if err == nil && w.closed {
+		_ = conn.Close()
+		return errors.New("syslog writer is closed")
+	}
+	if err != nil {
+		switch {
+		case w.backoff == 0:
+			w.backoff = syslogMinBackoff
+		case w.backoff < syslogMaxBackoff:
+			w.backoff = min(2*w.backoff, syslogMaxBackoff)
+		}
+		w.nextDial = now.Add(w.backoff)
+		return errors.Wrapf(err, "error connecting to syslog server %s", w.address)
+	}
+
+	w.conn, w.backoff, w.nextDial = conn, 0, time.Time{}
+	if w.framed {
+		go w.watch(conn)
+	}
+	return nil
+}
+
+func (w *SyslogWriter) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: syslogDialTimeout}
+	if w.tlsConfig != nil {
+		return tls.DialWithDialer(dialer, w.network, w.address, w.tlsConfig)
+	}
+	return dialer.Dial(w.network, w.address)
+}
+
+// watch disconnects the writer when the server closes the connection. Syslog
+// servers do not send data, so any read ends when the connection is closed.
+func (w *SyslogWriter) watch(conn net.Conn) {
+	_, _ = io.Copy(io.Discard, conn)
+	w.mu.Lock()
+	if w.conn == conn {
+		w.disconnect()
+	}
+	w.mu.Unlock()
+}
+
+func (w *SyslogWriter) disconnect() {
+	if w.conn != nil {
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+}
+
+// Sync implements zapcore.WriteSyncer, messages are not buffered.
+func (w *SyslogWriter) Sync() error {
+	return nil
+}
+
+// Close closes the connection to the server.
+func (w *SyslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	w.disconnect()
+	return nil
+}
+
+// newSyslogEncoder returns the encoder used by a sink with the syslog format.
+func newSyslogEncoder(s *Syslog, config zapcore.EncoderConfig) zapcore.Encoder {
+	return encoder.NewSyslogEncoder(config, s.encoderConfig())
+}
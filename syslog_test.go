@@ -0,0 +1,357 @@
+package logging
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// readFrame reads a message framed with octet counting.
+func readFrame(r *bufio.Reader) (string, error) {
+	s, err := r.ReadString(' ')
+	if err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// serveFrames accepts connections in l and sends the messages received to
+// the returned channel. Each connection is closed after reading max messages.
+func serveFrames(t *testing.T, l net.Listener, max int) <-chan string {
+	t.Helper()
+	ch := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(conn)
+			for i := 0; i < max; i++ {
+				msg, err := readFrame(r)
+				if err != nil {
+					break
+				}
+				ch <- msg
+			}
+			conn.Close()
+		}
+	}()
+	return ch
+}
+
+func receive(t *testing.T, ch <-chan string) string {
+	t.Helper()
+	select {
+	case msg := <-ch:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for syslog message")
+		return ""
+	}
+}
+
+func TestSyslog_tcp(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	ch := serveFrames(t, l, 10)
+
+	logger, err := New("test",
+		WithLogLevel(DebugLevel),
+		WithConfig([]byte(fmt.Sprintf(`{"sinks":[{"output":"tcp://%s","syslog":{"facility":"local0","hostname":"host","appName":"app"}}]}`, l.Addr()))),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer logger.Close()
+
+	logger.Warn("warn message", zap.String("key", `a "quoted" value]`), zap.Int("n", 1))
+	re := regexp.MustCompile(`^<132>1 \d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}\S+ host app \d+ - ` +
+		`\[fields@32473 key="a \\"quoted\\" value\\]" n="1"\] warn message$`)
+	if msg := receive(t, ch); !re.MatchString(msg) {
+		t.Errorf("unexpected message %q", msg)
+	}
+
+	logger.Named("child").Debug("debug message")
+	if msg := receive(t, ch); !strings.HasPrefix(msg, "<135>1 ") || !strings.HasSuffix(msg, " test.child - debug message") {
+		t.Errorf("unexpected message %q", msg)
+	}
+}
+
+func TestSyslogWriter_reconnect(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	// The server closes the connection after each message.
+	ch := serveFrames(t, l, 1)
+
+	w, err := NewSyslogWriter("tcp://"+l.Addr().String(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	for _, s := range []string{"first", "second"} {
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatalf("SyslogWriter.Write() error = %v", err)
+		}
+		if msg := receive(t, ch); msg != s {
+			t.Errorf("unexpected message %q", msg)
+		}
+		// Wait until the writer sees the closed connection.
+		for i := 0; i < 100; i++ {
+			w.mu.Lock()
+			conn := w.conn
+			w.mu.Unlock()
+			if conn == nil {
+				break
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+}
+
+func TestSyslog_udp(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	logger, err := New("test", WithConfig([]byte(fmt.Sprintf(`{"sinks":[{"output":"udp://%s"}]}`, conn.LocalAddr()))))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer logger.Close()
+
+	logger.Error("error message")
+	b := make([]byte, 2048)
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := string(b[:n]); !strings.HasPrefix(msg, "<11>1 ") || !strings.HasSuffix(msg, " - - error message") {
+		t.Errorf("unexpected message %q", msg)
+	}
+}
+
+func TestSyslog_tls(t *testing.T) {
+	dir := t.TempDir()
+	cert := writeTestCertificate(t, dir)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert.Leaf)
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	ch := serveFrames(t, l, 1)
+
+	w, err := NewSyslogWriter("tls://"+l.Addr().String(), &Syslog{
+		TLS: &SyslogTLS{
+			CA:         filepath.Join(dir, "cert.pem"),
+			Cert:       filepath.Join(dir, "cert.pem"),
+			Key:        filepath.Join(dir, "key.pem"),
+			ServerName: "localhost",
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewSyslogWriter() error = %v", err)
+	}
+	defer w.Close()
+
+	if _, err := w.Write([]byte("<14>1 - - - - - - message\n")); err != nil {
+		t.Fatalf("SyslogWriter.Write() error = %v", err)
+	}
+	if msg := receive(t, ch); msg != "<14>1 - - - - - - message" {
+		t.Errorf("unexpected message %q", msg)
+	}
+}
+
+func TestSyslogWriter_backoff(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	w, err := NewSyslogWriter("tcp://"+addr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	w.now = func() time.Time { return now }
+
+	if _, err := w.Write([]byte("message")); err == nil {
+		t.Fatal("SyslogWriter.Write() error = nil, wantErr true")
+	}
+	if w.backoff != syslogMinBackoff {
+		t.Errorf("SyslogWriter.backoff = %v, want %v", w.backoff, syslogMinBackoff)
+	}
+
+	// Writes fail without dialing until the backoff elapses.
+	if l, err = net.Listen("tcp", addr); err != nil {
+		t.Skipf("cannot listen again in %s: %v", addr, err)
+	}
+	defer l.Close()
+	ch := serveFrames(t, l, 1)
+	if _, err := w.Write([]byte("message")); err == nil {
+		t.Fatal("SyslogWriter.Write() error = nil, wantErr true")
+	}
+
+	now = now.Add(syslogMinBackoff)
+	if _, err := w.Write([]byte("message")); err != nil {
+		t.Fatalf("SyslogWriter.Write() error = %v", err)
+	}
+	if msg := receive(t, ch); msg != "message" {
+		t.Errorf("unexpected message %q", msg)
+	}
+	if w.backoff != 0 {
+		t.Errorf("SyslogWriter.backoff = %v, want 0", w.backoff)
+	}
+}
+
+func TestSyslogWriter_dialUnlocked(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := l.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+
+	// The TLS handshake blocks because the server never answers.
+	w, err := NewSyslogWriter("tls://"+l.Addr().String(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.Write([]byte("message"))
+		done <- err
+	}()
+	conn := <-accepted
+	defer conn.Close()
+
+	closed := make(chan struct{})
+	go func() {
+		w.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("SyslogWriter.Close() is blocked by the dial")
+	}
+
+	conn.Close()
+	if err := <-done; err == nil {
+		t.Error("SyslogWriter.Write() error = nil, wantErr true")
+	}
+}
+
+func TestSink_validateSyslog(t *testing.T) {
+	c := DefaultConfig()
+	c.Sinks = []*Sink{
+		{Output: "tcp://localhost", Levels: AllLevels()},
+		{Output: "udp://localhost:514", Format: "json", Levels: AllLevels(), Syslog: &Syslog{Facility: "foo", TLS: &SyslogTLS{}}},
+	}
+	err := c.Validate()
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("Config.Validate() error = %v, want ValidationErrors", err)
+	}
+	var paths []string
+	for _, e := range errs {
+		paths = append(paths, e.Path)
+	}
+	want := "sinks[0].output sinks[1].format sinks[1].syslog.facility sinks[1].syslog.tls"
+	if got := strings.Join(paths, " "); got != want {
+		t.Errorf("Config.Validate() paths = %q, want %q", got, want)
+	}
+}
+
+// writeTestCertificate writes a self-signed certificate for localhost, valid
+// for servers and clients, to cert.pem and key.pem in dir.
+func writeTestCertificate(t *testing.T, dir string) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	if err := os.WriteFile(filepath.Join(dir, "cert.pem"), certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "key.pem"), keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}